@@ -1,11 +1,14 @@
 package cmd
 
 import (
-	"flag"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
@@ -18,64 +21,137 @@ import (
 	"github.com/vsc-blockchain/pricefeeder/utils"
 )
 
-func setupLogger() zerolog.Logger {
+func setupLogger(debug bool) zerolog.Logger {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	debug := flag.Bool("debug", false, "sets log level to debug")
-	flag.Parse()
 	// Default level is INFO, unless debug flag is present
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	if *debug {
+	if debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
 
 	return zerolog.New(os.Stderr).With().Timestamp().Logger()
 }
 
-// handleInterrupt listens for SIGINT and gracefully shuts down the feeder.
-func handleInterrupt(logger zerolog.Logger, f *feeder.Feeder) {
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
+// waitForSignal blocks until SIGINT or SIGTERM is received.
+func waitForSignal() os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	return <-signals
+}
 
-	go func() {
-		<-interrupt
-		logger.Info().Msg("shutting down gracefully")
-		f.Close()
-		os.Exit(1)
-	}()
+// shutdown stops the feeder, letting an in-flight vote complete, then the metrics server,
+// both within the given timeout. The metrics server is closed even if the feeder did not stop in time.
+func shutdown(logger zerolog.Logger, f *feeder.Feeder, server *http.Server, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := f.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop feeder: %w", err))
+	} else {
+		logger.Info().Msg("feeder stopped")
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		_ = server.Close()
+		errs = append(errs, fmt.Errorf("failed to stop metrics server: %w", err))
+	}
+	return errors.Join(errs...)
 }
 
 var rootCmd = &cobra.Command{
 	Use:   "pricefeeder",
 	Short: "Pricefeeder daemon for posting prices to VSC Chain",
-	Run: func(cmd *cobra.Command, args []string) {
-		logger := setupLogger()
+	// errors are printed by Execute, and are not usage errors
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		debug, _ := cmd.Flags().GetBool("debug")
+		logger := setupLogger(debug)
 
 		utils.InitSDKConfig()
 
-		c := config.MustGet()
-
-		eventStream := eventstream.Dial(c.WebsocketEndpoint, c.GRPCEndpoint, c.EnableTLS, logger)
-		priceProvider := priceprovider.NewAggregatePriceProvider(c.ExchangesToPairToSymbolMap, c.DataSourceConfigMap, logger)
-		kb, valAddr, feederAddr := config.GetAuth(c.FeederMnemonic)
+		configFile, _ := cmd.Flags().GetString("config")
+		c := config.MustGet(configFile)
+
+		eventStream := eventstream.Dial(c.WebsocketEndpoints, c.GRPCEndpoints, c.EnableTLS, c.VotePeriodEvent, c.ParamsPollInterval, logger)
+		priceProvider := priceprovider.NewAggregatePriceProvider(
+			c.ExchangesToPairToSymbolMap,
+			c.DataSourceConfigMap,
+			c.AggregationConfig,
+			c.PairAggregationConfigMap,
+			logger,
+		)
+		kb, valAddr, feederAddr, err := config.GetAuth(c)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to load feeder key")
+		}
 
 		if c.ValidatorAddr != nil {
 			valAddr = *c.ValidatorAddr
 		}
-		pricePoster := priceposter.Dial(c.GRPCEndpoint, c.ChainID, c.EnableTLS, kb, valAddr, feederAddr, logger)
+		pricePoster := priceposter.Dial(c.GRPCEndpoints, c.ChainID, c.EnableTLS, kb, valAddr, feederAddr, c.DeviationGuard, c.Fees, c.StateFile, logger)
 
 		f := feeder.NewFeeder(eventStream, priceProvider, pricePoster, logger)
 		f.Run()
-		defer f.Close()
 
-		handleInterrupt(logger, f)
-
-		http.Handle("/metrics", promhttp.Handler())
-		http.ListenAndServe(":8080", nil)
+		// symbols maps, source settings and aggregation are reloaded without restarting,
+		// other settings require a restart.
+		watcher, err := config.NewWatcher(configFile, logger, func(c *config.Config) {
+			err := priceProvider.Reload(
+				c.ExchangesToPairToSymbolMap,
+				c.DataSourceConfigMap,
+				c.AggregationConfig,
+				c.PairAggregationConfigMap,
+			)
+			if err != nil {
+				logger.Err(err).Msg("failed to reload price providers")
+				return
+			}
+			logger.Info().Msg("reloaded price providers")
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to watch config")
+		}
 
-		select {}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			if !f.Healthy() {
+				http.Error(w, "event stream disconnected", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		})
+		server := &http.Server{Addr: ":8080", Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Err(err).Msg("metrics server failed")
+			}
+		}()
+
+		sig := waitForSignal()
+		shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+		logger.Info().Str("signal", sig.String()).Dur("timeout", shutdownTimeout).Msg("shutting down gracefully")
+		// the watcher is stopped first, so that no reload restarts the sources being closed
+		watcher.Close()
+		if err := shutdown(logger, f, server, shutdownTimeout); err != nil {
+			// Execute exits with code 1, the rest of the shutdown having run
+			logger.Err(err).Msg("failed to shut down gracefully")
+			return err
+		}
+		logger.Info().Msg("shut down")
+		return nil
 	},
 }
 
+func init() {
+	rootCmd.Flags().Bool("debug", false, "sets log level to debug")
+	rootCmd.Flags().String("config", "", "path to a YAML or TOML config file, overridden by the environment")
+	// an in-flight vote may take up to priceposter.VoteTimeout, the margin leaves time to close the connections
+	rootCmd.Flags().Duration("shutdown-timeout", priceposter.VoteTimeout+10*time.Second, "time given to an in-flight vote to complete on SIGINT or SIGTERM")
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -4,15 +4,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/joho/godotenv"
 	"github.com/vsc-blockchain/core/x/common/asset"
+	"github.com/vsc-blockchain/core/x/common/set"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceposter"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/aggregation"
 	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/sources"
 	"github.com/vsc-blockchain/pricefeeder/types"
 )
 
+// defaultParamsPollInterval is the interval between two queries of the oracle params,
+// which are also queried whenever a governance proposal ends.
+const defaultParamsPollInterval = time.Minute
+
 var defaultExchangeSymbolsMap = map[string]map[asset.Pair]types.Symbol{
 	// https://api.coingecko.com/api/v3/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=100&page=1
 	// k-yang: default disable Coingecko because they have aggressive rate limiting
@@ -67,10 +81,76 @@ var defaultExchangeSymbolsMap = map[string]map[asset.Pair]types.Symbol{
 	},*/
 }
 
-func MustGet() *Config {
-	conf, err := Get()
+// defaultAggregationKey is the AGGREGATION_CONFIG_MAP key
+// of the aggregation config used for pairs without one.
+const defaultAggregationKey = "default"
+
+// knownSources are the names of the sources the feeder can fetch prices from.
+var knownSources = set.New(
+	sources.Bitfinex,
+	sources.Binance,
+	sources.Coingecko,
+	sources.Okex,
+	sources.GateIo,
+	sources.CoinMarketCap,
+	sources.Bybit,
+	sources.Uniswap,
+	sources.Mexc,
+	sources.Ascendex,
+)
+
+// deviationGuardConfig is the DEVIATION_GUARD_CONFIG schema.
+type deviationGuardConfig struct {
+	MaxDeviation float64            `json:"max_deviation"`
+	Pairs        map[string]float64 `json:"pairs"`
+	Abstain      bool               `json:"abstain"`
+}
+
+// feeConfig is the FEE_CONFIG schema, gas prices are decimal strings.
+type feeConfig struct {
+	Denom         string  `json:"denom"`
+	GasPrice      string  `json:"gas_price"`
+	GasLimit      uint64  `json:"gas_limit"`
+	Simulate      bool    `json:"simulate"`
+	GasAdjustment float64 `json:"gas_adjustment"`
+	MaxGasPrice   string  `json:"max_gas_price"`
+}
+
+// fileConfig is the schema of the config file. Each key is the lowercase name
+// of the environment variable which overrides it, and map values share the
+// schema of the JSON environment variables.
+type fileConfig struct {
+	ChainID              string                        `json:"chain_id"`
+	GRPCEndpoint         string                        `json:"grpc_endpoint"`
+	WebsocketEndpoint    string                        `json:"websocket_endpoint"`
+	FeederMnemonic       string                        `json:"feeder_mnemonic"`
+	KeyringBackend       string                        `json:"keyring_backend"`
+	KeyringDir           string                        `json:"keyring_dir"`
+	KeyName              string                        `json:"key_name"`
+	KeystoreFile         string                        `json:"keystore_file"`
+	PassphraseFile       string                        `json:"passphrase_file"`
+	HDPath               string                        `json:"hd_path"`
+	CoinType             *uint32                       `json:"coin_type"`
+	RemoteSignerURL      string                        `json:"remote_signer_url"`
+	RemoteSignerTLSCert  string                        `json:"remote_signer_tls_cert"`
+	RemoteSignerTLSKey   string                        `json:"remote_signer_tls_key"`
+	RemoteSignerTLSCA    string                        `json:"remote_signer_tls_ca"`
+	EnableTLS            bool                          `json:"enable_tls"`
+	ValidatorAddress     string                        `json:"validator_address"`
+	ExchangeSymbolsMap   map[string]map[string]string  `json:"exchange_symbols_map"`
+	DatasourceConfigMap  map[string]json.RawMessage    `json:"datasource_config_map"`
+	AggregationConfigMap map[string]aggregation.Config `json:"aggregation_config_map"`
+	DeviationGuardConfig *deviationGuardConfig         `json:"deviation_guard_config"`
+	FeeConfig            *feeConfig                    `json:"fee_config"`
+	StateFile            string                        `json:"state_file"`
+	VotePeriodEvent      string                        `json:"vote_period_event"`
+	ParamsPollInterval   string                        `json:"params_poll_interval"`
+}
+
+func MustGet(configFile string) *Config {
+	conf, err := Get(configFile)
 	if err != nil {
-		panic(fmt.Sprintf("config error! check the environment: %v", err))
+		panic(fmt.Sprintf("config error! check the config file and the environment:\n%v", err))
 	}
 
 	if conf == nil {
@@ -80,81 +160,395 @@ func MustGet() *Config {
 	return conf
 }
 
-// Get loads the configuration from the .env file and returns a Config struct or an error
-// if the configuration is invalid.
-func Get() (*Config, error) {
+// Get loads the configuration from the optional config file, then from the .env file and
+// the environment, which override the config file, and returns a Config struct or the
+// Errors of an invalid configuration.
+func Get(configFile string) (*Config, error) {
+	raw := new(fileConfig)
+	if configFile != "" {
+		if err := loadFile(configFile, raw); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", configFile, err)
+		}
+	}
+
 	_ = godotenv.Load() // .env is optional
 
+	var errs Errors
+	overrideString(&raw.ChainID, "CHAIN_ID")
+	overrideString(&raw.GRPCEndpoint, "GRPC_ENDPOINT")
+	overrideString(&raw.WebsocketEndpoint, "WEBSOCKET_ENDPOINT")
+	overrideString(&raw.FeederMnemonic, "FEEDER_MNEMONIC")
+	overrideString(&raw.KeyringBackend, "KEYRING_BACKEND")
+	overrideString(&raw.KeyringDir, "KEYRING_DIR")
+	overrideString(&raw.KeyName, "KEY_NAME")
+	overrideString(&raw.KeystoreFile, "KEYSTORE_FILE")
+	overrideString(&raw.PassphraseFile, "PASSPHRASE_FILE")
+	overrideString(&raw.HDPath, "HD_PATH")
+	overrideString(&raw.RemoteSignerURL, "REMOTE_SIGNER_URL")
+	overrideString(&raw.RemoteSignerTLSCert, "REMOTE_SIGNER_TLS_CERT")
+	overrideString(&raw.RemoteSignerTLSKey, "REMOTE_SIGNER_TLS_KEY")
+	overrideString(&raw.RemoteSignerTLSCA, "REMOTE_SIGNER_TLS_CA")
+	if coinTypeStr := os.Getenv("COIN_TYPE"); coinTypeStr != "" {
+		coinType, err := strconv.ParseUint(coinTypeStr, 10, 32)
+		if err != nil {
+			errs.add(err, "COIN_TYPE")
+		} else {
+			raw.CoinType = new(uint32)
+			*raw.CoinType = uint32(coinType)
+		}
+	}
+	overrideString(&raw.ValidatorAddress, "VALIDATOR_ADDRESS")
+	overrideString(&raw.StateFile, "STATE_FILE")
+	overrideString(&raw.VotePeriodEvent, "VOTE_PERIOD_EVENT")
+	overrideString(&raw.ParamsPollInterval, "PARAMS_POLL_INTERVAL")
+	if enableTLS := os.Getenv("ENABLE_TLS"); enableTLS != "" {
+		raw.EnableTLS = enableTLS == "true"
+	}
+
+	// JSON environment variables override the config file per exchange,
+	// per source, or entirely for the aggregation and the deviation guard.
+	exchangeSymbolsMap := map[string]map[string]string{}
+	if errs.parseEnv("EXCHANGE_SYMBOLS_MAP", &exchangeSymbolsMap) {
+		raw.ExchangeSymbolsMap = merge(raw.ExchangeSymbolsMap, exchangeSymbolsMap)
+	}
+	datasourceConfigMap := map[string]json.RawMessage{}
+	if errs.parseEnv("DATASOURCE_CONFIG_MAP", &datasourceConfigMap) {
+		raw.DatasourceConfigMap = merge(raw.DatasourceConfigMap, datasourceConfigMap)
+	}
+	aggregationConfigMap := map[string]aggregation.Config{}
+	if errs.parseEnv("AGGREGATION_CONFIG_MAP", &aggregationConfigMap) {
+		raw.AggregationConfigMap = aggregationConfigMap
+	}
+	deviationGuard := new(deviationGuardConfig)
+	if errs.parseEnv("DEVIATION_GUARD_CONFIG", deviationGuard) {
+		raw.DeviationGuardConfig = deviationGuard
+	}
+	fees := new(feeConfig)
+	if errs.parseEnv("FEE_CONFIG", fees) {
+		raw.FeeConfig = fees
+	}
+
 	conf := new(Config)
-	conf.ChainID = os.Getenv("CHAIN_ID")
-	conf.GRPCEndpoint = os.Getenv("GRPC_ENDPOINT")
-	conf.WebsocketEndpoint = os.Getenv("WEBSOCKET_ENDPOINT")
-	conf.FeederMnemonic = os.Getenv("FEEDER_MNEMONIC")
-	conf.EnableTLS = os.Getenv("ENABLE_TLS") == "true"
-	conf.ExchangesToPairToSymbolMap = defaultExchangeSymbolsMap
-
-	overrideExchangeSymbolsMapJson := os.Getenv("EXCHANGE_SYMBOLS_MAP")
-	if overrideExchangeSymbolsMapJson != "" {
-		overrideExchangeSymbolsMap := map[string]map[string]string{}
-		err := json.Unmarshal([]byte(overrideExchangeSymbolsMapJson), &overrideExchangeSymbolsMap)
+	conf.ChainID = raw.ChainID
+	conf.GRPCEndpoints = splitList(raw.GRPCEndpoint)
+	conf.WebsocketEndpoints = splitList(raw.WebsocketEndpoint)
+	conf.FeederMnemonic = raw.FeederMnemonic
+	conf.KeyringBackend = raw.KeyringBackend
+	conf.KeyringDir = raw.KeyringDir
+	conf.KeyName = raw.KeyName
+	conf.KeystoreFile = raw.KeystoreFile
+	conf.PassphraseFile = raw.PassphraseFile
+	conf.RemoteSignerURL = raw.RemoteSignerURL
+	conf.RemoteSignerTLSCert = raw.RemoteSignerTLSCert
+	conf.RemoteSignerTLSKey = raw.RemoteSignerTLSKey
+	conf.RemoteSignerTLSCA = raw.RemoteSignerTLSCA
+	conf.EnableTLS = raw.EnableTLS
+	conf.StateFile = raw.StateFile
+	conf.VotePeriodEvent = raw.VotePeriodEvent
+	conf.ParamsPollInterval = defaultParamsPollInterval
+	if raw.ParamsPollInterval != "" {
+		interval, err := time.ParseDuration(raw.ParamsPollInterval)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse EXCHANGE_SYMBOLS_MAP: %w", err)
+			errs.add(err, "params_poll_interval")
+		} else {
+			conf.ParamsPollInterval = interval
 		}
-		for exchange, symbolMap := range overrideExchangeSymbolsMap {
-			conf.ExchangesToPairToSymbolMap[exchange] = map[asset.Pair]types.Symbol{}
-			for nibiAssetPair, tickerSymbol := range symbolMap {
-				conf.ExchangesToPairToSymbolMap[exchange][asset.MustNewPair(nibiAssetPair)] = types.Symbol(tickerSymbol)
+	}
+
+	// the HD path of the mnemonic defaults to the first account of the coin type
+	conf.HDPath = raw.HDPath
+	if conf.HDPath == "" {
+		coinType := uint32(sdk.CoinType)
+		if raw.CoinType != nil {
+			coinType = *raw.CoinType
+		}
+		conf.HDPath = hd.CreateHDPath(coinType, 0, 0).String()
+	}
+
+	// symbols maps override the default one per exchange
+	conf.ExchangesToPairToSymbolMap = map[string]map[asset.Pair]types.Symbol{}
+	for exchange, symbolMap := range defaultExchangeSymbolsMap {
+		conf.ExchangesToPairToSymbolMap[exchange] = symbolMap
+	}
+	for exchange, symbolMap := range raw.ExchangeSymbolsMap {
+		conf.ExchangesToPairToSymbolMap[exchange] = map[asset.Pair]types.Symbol{}
+		for pairStr, tickerSymbol := range symbolMap {
+			pair, err := asset.TryNewPair(pairStr)
+			if err != nil {
+				errs.add(err, "exchange_symbols_map", exchange, pairStr)
+				continue
 			}
+			conf.ExchangesToPairToSymbolMap[exchange][pair] = types.Symbol(tickerSymbol)
 		}
 	}
 
-	// datasource config map
-	datasourceConfigMapJson := os.Getenv("DATASOURCE_CONFIG_MAP")
-	datasourceConfigMap := map[string]json.RawMessage{}
+	conf.DataSourceConfigMap = raw.DatasourceConfigMap
+	if conf.DataSourceConfigMap == nil {
+		conf.DataSourceConfigMap = map[string]json.RawMessage{}
+	}
 
-	if datasourceConfigMapJson != "" {
-		err := json.Unmarshal([]byte(datasourceConfigMapJson), &datasourceConfigMap)
+	conf.AggregationConfig = aggregation.Config{Strategy: aggregation.Median}
+	conf.PairAggregationConfigMap = map[asset.Pair]aggregation.Config{}
+	for key, aggregationConfig := range raw.AggregationConfigMap {
+		if key == defaultAggregationKey {
+			conf.AggregationConfig = aggregationConfig
+			continue
+		}
+		pair, err := asset.TryNewPair(key)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse DATASOURCE_CONFIG_MAP: invalid json")
+			errs.add(err, "aggregation_config_map", key)
+			continue
+		}
+		conf.PairAggregationConfigMap[pair] = aggregationConfig
+	}
+
+	if raw.DeviationGuardConfig != nil {
+		conf.DeviationGuard = priceposter.DeviationGuard{
+			MaxDeviation:      raw.DeviationGuardConfig.MaxDeviation,
+			PairMaxDeviations: map[asset.Pair]float64{},
+			Abstain:           raw.DeviationGuardConfig.Abstain,
+		}
+		for pairStr, maxDeviation := range raw.DeviationGuardConfig.Pairs {
+			pair, err := asset.TryNewPair(pairStr)
+			if err != nil {
+				errs.add(err, "deviation_guard_config", "pairs", pairStr)
+				continue
+			}
+			conf.DeviationGuard.PairMaxDeviations[pair] = maxDeviation
+		}
+	}
+
+	// unset fee settings default to the ones of priceposter.DefaultFeeConfig
+	conf.Fees = priceposter.DefaultFeeConfig
+	if raw.FeeConfig != nil {
+		if raw.FeeConfig.Denom != "" {
+			conf.Fees.Denom = raw.FeeConfig.Denom
+		}
+		if raw.FeeConfig.GasPrice != "" {
+			gasPrice, err := sdkmath.LegacyNewDecFromStr(raw.FeeConfig.GasPrice)
+			if err != nil {
+				errs.add(err, "fee_config", "gas_price")
+			} else {
+				conf.Fees.GasPrice = gasPrice
+			}
+		}
+		if raw.FeeConfig.GasLimit != 0 {
+			conf.Fees.GasLimit = raw.FeeConfig.GasLimit
+		}
+		conf.Fees.Simulate = raw.FeeConfig.Simulate
+		if raw.FeeConfig.GasAdjustment != 0 {
+			conf.Fees.GasAdjustment = raw.FeeConfig.GasAdjustment
+		}
+		if raw.FeeConfig.MaxGasPrice != "" {
+			maxGasPrice, err := sdkmath.LegacyNewDecFromStr(raw.FeeConfig.MaxGasPrice)
+			if err != nil {
+				errs.add(err, "fee_config", "max_gas_price")
+			} else {
+				conf.Fees.MaxGasPrice = maxGasPrice
+			}
 		}
 	}
-	conf.DataSourceConfigMap = datasourceConfigMap
 
 	// optional validator address (for delegated feeders)
-	valAddrStr := os.Getenv("VALIDATOR_ADDRESS")
-	if valAddrStr != "" {
-		valAddr, err := sdk.ValAddressFromBech32(valAddrStr)
-		if err == nil {
+	if raw.ValidatorAddress != "" {
+		valAddr, err := sdk.ValAddressFromBech32(raw.ValidatorAddress)
+		if err != nil {
+			errs.add(err, "validator_address")
+		} else {
 			conf.ValidatorAddr = &valAddr
 		}
 	}
 
-	return conf, conf.Validate()
+	errs = append(errs, conf.validate()...)
+	return conf, errs.Err()
 }
 
 type Config struct {
 	ExchangesToPairToSymbolMap map[string]map[asset.Pair]types.Symbol
 	DataSourceConfigMap        map[string]json.RawMessage
-	GRPCEndpoint               string
-	WebsocketEndpoint          string
+	AggregationConfig          aggregation.Config
+	PairAggregationConfigMap   map[asset.Pair]aggregation.Config
+	DeviationGuard             priceposter.DeviationGuard
+	Fees                       priceposter.FeeConfig
+	GRPCEndpoints              []string
+	WebsocketEndpoints         []string
 	FeederMnemonic             string
+	KeyringBackend             string
+	KeyringDir                 string
+	KeyName                    string
+	KeystoreFile               string
+	PassphraseFile             string
+	HDPath                     string
+	RemoteSignerURL            string
+	RemoteSignerTLSCert        string
+	RemoteSignerTLSKey         string
+	RemoteSignerTLSCA          string
 	ChainID                    string
 	ValidatorAddr              *sdk.ValAddress
 	EnableTLS                  bool
+	StateFile                  string
+	VotePeriodEvent            string
+	ParamsPollInterval         time.Duration
 }
 
+// Validate returns the Errors listing every problem of the Config, or nil if it is valid.
 func (c *Config) Validate() error {
+	return c.validate().Err()
+}
+
+func (c *Config) validate() Errors {
+	var errs Errors
 	if c.ChainID == "" {
-		return fmt.Errorf("no chain id")
+		errs.add(errMissing, "chain_id")
+	}
+	errs = append(errs, c.validateKey()...)
+	if len(c.WebsocketEndpoints) == 0 {
+		errs.add(errMissing, "websocket_endpoint")
+	}
+	for _, endpoint := range c.WebsocketEndpoints {
+		if u, err := url.Parse(endpoint); err != nil {
+			errs.add(err, "websocket_endpoint")
+		} else if u.Scheme != "ws" && u.Scheme != "wss" {
+			errs.add(fmt.Errorf("invalid scheme %q of %s, expected ws or wss", u.Scheme, endpoint), "websocket_endpoint")
+		}
+	}
+	if len(c.GRPCEndpoints) == 0 {
+		errs.add(errMissing, "grpc_endpoint")
+	}
+	for exchange := range c.ExchangesToPairToSymbolMap {
+		if !knownSources.Has(exchange) {
+			errs.add(errUnknownSource, "exchange_symbols_map", exchange)
+		}
+	}
+	for source, sourceConfig := range c.DataSourceConfigMap {
+		if !knownSources.Has(source) {
+			errs.add(errUnknownSource, "datasource_config_map", source)
+			continue
+		}
+		if !json.Valid(sourceConfig) {
+			errs.add(errors.New("invalid json"), "datasource_config_map", source)
+			continue
+		}
+		if source == sources.Uniswap {
+			if err := sources.ValidateUniswapConfig(sourceConfig); err != nil {
+				errs.add(err, "datasource_config_map", source)
+			}
+		}
+	}
+	if _, ok := c.ExchangesToPairToSymbolMap[sources.CoinMarketCap]; ok {
+		apiKey := struct {
+			ApiKey string `json:"api_key"`
+		}{}
+		_ = json.Unmarshal(c.DataSourceConfigMap[sources.CoinMarketCap], &apiKey)
+		if apiKey.ApiKey == "" {
+			errs.add(errMissing, "datasource_config_map", sources.CoinMarketCap, "api_key")
+		}
+	}
+	if _, err := aggregation.New(c.AggregationConfig, nil); err != nil {
+		errs.add(err, "aggregation_config_map", defaultAggregationKey)
+	}
+	for pair, aggregationConfig := range c.PairAggregationConfigMap {
+		if _, err := aggregation.New(aggregationConfig, nil); err != nil {
+			errs.add(err, "aggregation_config_map", pair.String())
+		}
+	}
+	if c.ParamsPollInterval <= 0 {
+		errs.add(errors.New("not positive"), "params_poll_interval")
+	}
+	if c.DeviationGuard.MaxDeviation < 0 {
+		errs.add(errNegative, "deviation_guard_config", "max_deviation")
+	}
+	for pair, maxDeviation := range c.DeviationGuard.PairMaxDeviations {
+		if maxDeviation < 0 {
+			errs.add(errNegative, "deviation_guard_config", "pairs", pair.String())
+		}
+	}
+	if err := sdk.ValidateDenom(c.Fees.Denom); err != nil {
+		errs.add(err, "fee_config", "denom")
+	}
+	if c.Fees.GasPrice.IsNegative() {
+		errs.add(errNegative, "fee_config", "gas_price")
 	}
-	if c.FeederMnemonic == "" {
-		return fmt.Errorf("no feeder mnemonic")
+	if c.Fees.GasAdjustment < 1 {
+		errs.add(errors.New("must be at least 1"), "fee_config", "gas_adjustment")
 	}
-	if c.WebsocketEndpoint == "" {
-		return fmt.Errorf("no websocket endpoint")
+	if !c.Fees.MaxGasPrice.IsNil() && c.Fees.MaxGasPrice.IsNegative() {
+		errs.add(errNegative, "fee_config", "max_gas_price")
+	}
+	return errs
+}
+
+// validateKey checks that the feeder key is provided by exactly one of
+// the mnemonic, a keyring backend, an encrypted keystore or a remote signer.
+func (c *Config) validateKey() Errors {
+	var errs Errors
+	var keySources []string
+	if c.FeederMnemonic != "" {
+		keySources = append(keySources, "feeder_mnemonic")
+	}
+	if c.KeyringBackend != "" {
+		keySources = append(keySources, "keyring_backend")
+	}
+	if c.KeystoreFile != "" {
+		keySources = append(keySources, "keystore_file")
+	}
+	if c.RemoteSignerURL != "" {
+		keySources = append(keySources, "remote_signer_url")
+	}
+	if len(keySources) == 0 {
+		errs.add(errMissing, "feeder_mnemonic")
+	} else {
+		for _, keySource := range keySources[1:] {
+			errs.add(fmt.Errorf("conflicts with %s", keySources[0]), keySource)
+		}
+	}
+
+	switch c.KeyringBackend {
+	case "":
+	case keyring.BackendFile, keyring.BackendOS, keyring.BackendTest:
+		if c.KeyName == "" {
+			errs.add(errMissing, "key_name")
+		}
+	default:
+		errs.add(errors.New("unsupported keyring backend"), "keyring_backend")
+	}
+	if c.KeystoreFile != "" && c.PassphraseFile == "" {
+		errs.add(errMissing, "passphrase_file")
+	}
+	if c.RemoteSignerURL != "" {
+		errs = append(errs, c.validateRemoteSigner()...)
+	}
+	if _, err := hd.NewParamsFromPath(c.HDPath); err != nil {
+		errs.add(err, "hd_path")
+	}
+	return errs
+}
+
+// overrideString sets the value of the environment variable, if any.
+func overrideString(value *string, env string) {
+	if v := os.Getenv(env); v != "" {
+		*value = v
+	}
+}
+
+// splitList returns the non-empty items of a comma-separated list.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// merge returns base with the keys of override replaced.
+func merge[V any](base, override map[string]V) map[string]V {
+	if base == nil {
+		base = map[string]V{}
 	}
-	if c.GRPCEndpoint == "" {
-		return fmt.Errorf("no grpc endpoint")
+	for k, v := range override {
+		base[k] = v
 	}
-	return nil
+	return base
 }
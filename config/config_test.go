@@ -3,9 +3,18 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceposter"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/aggregation"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/sources"
+	"github.com/vsc-blockchain/pricefeeder/types"
 	"github.com/vsc-blockchain/pricefeeder/utils"
 )
 
@@ -22,8 +31,8 @@ func TestConfig_Get(t *testing.T) {
 
 	utils.InitSDKConfig()
 
-	os.Setenv("VALIDATOR_ADDRESS", "nibivaloper1d7zygazerfwx4l362tnpcp0ramzm97xvv9ryxr")
-	_, err := Get()
+	os.Setenv("VALIDATOR_ADDRESS", sdk.ValAddress(make([]byte, 20)).String())
+	_, err := Get("")
 	require.NoError(t, err)
 }
 
@@ -35,7 +44,392 @@ func TestConfig_Without_EXCHANGE_SYMBOLS_MAP(t *testing.T) {
 	os.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
 	utils.InitSDKConfig()
 	os.Setenv("VALIDATOR_ADDRESS", "nibivaloper1d7zygazerfwx4l362tnpcp0ramzm97xvv9ryxr")
-	cfg, err := Get()
+	cfg, err := Get("")
 	fmt.Println(cfg)
 	require.NoError(t, err)
 }
+
+func TestConfig_AGGREGATION_CONFIG_MAP(t *testing.T) {
+	os.Setenv("CHAIN_ID", "vsc-localnet-0")
+	os.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	os.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	os.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+	defer os.Unsetenv("AGGREGATION_CONFIG_MAP")
+
+	t.Run("valid", func(t *testing.T) {
+		os.Setenv("AGGREGATION_CONFIG_MAP", `{"default": {"strategy": "mad"}, "uusdc:uusd": {"strategy": "trimmed_mean", "trim": 0.1}}`)
+		cfg, err := Get("")
+		require.NoError(t, err)
+		require.Equal(t, aggregation.MAD, cfg.AggregationConfig.Strategy)
+		require.Equal(t, aggregation.Config{Strategy: aggregation.TrimmedMean, Trim: 0.1}, cfg.PairAggregationConfigMap[asset.MustNewPair("uusdc:uusd")])
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		os.Setenv("AGGREGATION_CONFIG_MAP", `{"uusdc:uusd": {"strategy": "unknown"}}`)
+		_, err := Get("")
+		require.ErrorContains(t, err, "unknown aggregation strategy")
+	})
+
+	t.Run("invalid pair", func(t *testing.T) {
+		os.Setenv("AGGREGATION_CONFIG_MAP", `{"uusdc": {"strategy": "median"}}`)
+		_, err := Get("")
+		require.Error(t, err)
+	})
+}
+
+func TestConfig_DEVIATION_GUARD_CONFIG(t *testing.T) {
+	os.Setenv("CHAIN_ID", "vsc-localnet-0")
+	os.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	os.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	os.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+	defer os.Unsetenv("DEVIATION_GUARD_CONFIG")
+
+	os.Setenv("DEVIATION_GUARD_CONFIG", `{"max_deviation": 0.1, "pairs": {"uusdc:uusd": 0.01}, "abstain": true}`)
+	cfg, err := Get("")
+	require.NoError(t, err)
+	require.Equal(t, 0.1, cfg.DeviationGuard.MaxDeviation)
+	require.Equal(t, 0.01, cfg.DeviationGuard.PairMaxDeviations[asset.MustNewPair("uusdc:uusd")])
+	require.True(t, cfg.DeviationGuard.Abstain)
+
+	os.Setenv("DEVIATION_GUARD_CONFIG", `{"max_deviation": -0.1}`)
+	_, err = Get("")
+	require.Error(t, err)
+}
+
+func TestConfig_Endpoints(t *testing.T) {
+	t.Setenv("CHAIN_ID", "vsc-localnet-0")
+	t.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+
+	t.Run("lists", func(t *testing.T) {
+		t.Setenv("GRPC_ENDPOINT", "node0:9090, node1:9090,")
+		t.Setenv("WEBSOCKET_ENDPOINT", "ws://node0:26657/websocket,wss://node1/websocket")
+		cfg, err := Get("")
+		require.NoError(t, err)
+		require.Equal(t, []string{"node0:9090", "node1:9090"}, cfg.GRPCEndpoints)
+		require.Equal(t, []string{"ws://node0:26657/websocket", "wss://node1/websocket"}, cfg.WebsocketEndpoints)
+	})
+
+	t.Run("invalid websocket scheme", func(t *testing.T) {
+		t.Setenv("GRPC_ENDPOINT", "node0:9090")
+		t.Setenv("WEBSOCKET_ENDPOINT", "ws://node0:26657/websocket,http://node1:26657")
+		_, err := Get("")
+		require.ErrorContains(t, err, "websocket_endpoint")
+	})
+}
+
+func TestConfig_PARAMS_POLL_INTERVAL(t *testing.T) {
+	t.Setenv("CHAIN_ID", "vsc-localnet-0")
+	t.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	t.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	t.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+
+	t.Setenv("PARAMS_POLL_INTERVAL", "")
+	cfg, err := Get("")
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, cfg.ParamsPollInterval)
+
+	t.Setenv("PARAMS_POLL_INTERVAL", "30s")
+	cfg, err = Get("")
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, cfg.ParamsPollInterval)
+
+	for _, interval := range []string{"30", "0s"} {
+		t.Setenv("PARAMS_POLL_INTERVAL", interval)
+		_, err = Get("")
+		require.ErrorContains(t, err, "params_poll_interval")
+	}
+}
+
+func TestConfig_File(t *testing.T) {
+	t.Setenv("CHAIN_ID", "")
+	t.Setenv("GRPC_ENDPOINT", "")
+	t.Setenv("WEBSOCKET_ENDPOINT", "")
+	t.Setenv("FEEDER_MNEMONIC", "")
+	t.Setenv("VALIDATOR_ADDRESS", "")
+	t.Setenv("EXCHANGE_SYMBOLS_MAP", "")
+	t.Setenv("DATASOURCE_CONFIG_MAP", "")
+	t.Setenv("AGGREGATION_CONFIG_MAP", "")
+	t.Setenv("DEVIATION_GUARD_CONFIG", "")
+
+	files := map[string]string{
+		"config.yaml": `
+chain_id: vsc-localnet-0
+grpc_endpoint: localhost:9090
+websocket_endpoint: ws://localhost:26657/websocket
+feeder_mnemonic: earth wash broom grow recall fitness
+exchange_symbols_map:
+  bitfinex:
+    ubtc:uusd: tBTCUSD
+datasource_config_map:
+  coingecko:
+    api_key: "0123456789"
+aggregation_config_map:
+  default:
+    strategy: mad
+deviation_guard_config:
+  max_deviation: 0.1
+`,
+		"config.toml": `
+chain_id = "vsc-localnet-0"
+grpc_endpoint = "localhost:9090"
+websocket_endpoint = "ws://localhost:26657/websocket"
+feeder_mnemonic = "earth wash broom grow recall fitness"
+
+[exchange_symbols_map.bitfinex]
+"ubtc:uusd" = "tBTCUSD"
+
+[datasource_config_map.coingecko]
+api_key = "0123456789"
+
+[aggregation_config_map.default]
+strategy = "mad"
+
+[deviation_guard_config]
+max_deviation = 0.1
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			cfg, err := Get(path)
+			require.NoError(t, err)
+			require.Equal(t, "vsc-localnet-0", cfg.ChainID)
+			require.Equal(t, []string{"localhost:9090"}, cfg.GRPCEndpoints)
+			require.Equal(t, map[asset.Pair]types.Symbol{asset.MustNewPair("ubtc:uusd"): "tBTCUSD"}, cfg.ExchangesToPairToSymbolMap[sources.Bitfinex])
+			require.JSONEq(t, `{"api_key": "0123456789"}`, string(cfg.DataSourceConfigMap[sources.Coingecko]))
+			require.Equal(t, aggregation.MAD, cfg.AggregationConfig.Strategy)
+			require.Equal(t, 0.1, cfg.DeviationGuard.MaxDeviation)
+		})
+	}
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(files["config.yaml"]), 0o600))
+		t.Setenv("GRPC_ENDPOINT", "localhost:9091")
+		t.Setenv("EXCHANGE_SYMBOLS_MAP", `{"binance": {"ubtc:uusd": "BTCUSDT"}}`)
+
+		cfg, err := Get(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{"localhost:9091"}, cfg.GRPCEndpoints)
+		require.Equal(t, "vsc-localnet-0", cfg.ChainID)
+		// exchanges are overridden one by one
+		require.Equal(t, types.Symbol("tBTCUSD"), cfg.ExchangesToPairToSymbolMap[sources.Bitfinex][asset.MustNewPair("ubtc:uusd")])
+		require.Equal(t, types.Symbol("BTCUSDT"), cfg.ExchangesToPairToSymbolMap[sources.Binance][asset.MustNewPair("ubtc:uusd")])
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("chain_idd: vsc-localnet-0\n"), 0o600))
+		_, err := Get(path)
+		require.ErrorContains(t, err, "chain_idd")
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+		_, err := Get(path)
+		require.ErrorContains(t, err, "unsupported extension")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Setenv("CHAIN_ID", "")
+	t.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	t.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	t.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+	t.Setenv("VALIDATOR_ADDRESS", "")
+	t.Setenv("EXCHANGE_SYMBOLS_MAP", `{"bitfinexx": {"ubtc:uusd": "tBTCUSD"}, "bitfinex": {"ubtc": "tBTCUSD"}, "coinmarketcap": {"ubtc:uusd": "bitcoin"}}`)
+	t.Setenv("DATASOURCE_CONFIG_MAP", `{"uniswap": {"symbols": {"WETHUSDC": {"routes": [[{"address": "0x1"}]]}}}}`)
+	t.Setenv("AGGREGATION_CONFIG_MAP", `{"uusdc:uusd": {"strategy": "unknown"}}`)
+	t.Setenv("DEVIATION_GUARD_CONFIG", `{"max_deviation": -0.1}`)
+
+	_, err := Get("")
+	require.Error(t, err)
+
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	expected := []string{
+		"chain_id: missing",
+		"exchange_symbols_map.bitfinexx: unknown source",
+		"exchange_symbols_map.bitfinex.ubtc: ",
+		"datasource_config_map.coinmarketcap.api_key: missing",
+		`datasource_config_map.uniswap: invalid uniswap config: WETHUSDC: invalid pool address "0x1"`,
+		"aggregation_config_map.uusdc:uusd: unknown aggregation strategy",
+		"deviation_guard_config.max_deviation: negative",
+	}
+	require.Len(t, errs, len(expected))
+	for _, msg := range expected {
+		require.ErrorContains(t, err, msg)
+	}
+}
+
+func TestConfig_FeederKey(t *testing.T) {
+	t.Setenv("CHAIN_ID", "vsc-localnet-0")
+	t.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	t.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	t.Setenv("VALIDATOR_ADDRESS", "")
+	t.Setenv("EXCHANGE_SYMBOLS_MAP", "")
+	t.Setenv("DATASOURCE_CONFIG_MAP", "")
+	t.Setenv("AGGREGATION_CONFIG_MAP", "")
+	t.Setenv("DEVIATION_GUARD_CONFIG", "")
+	t.Setenv("FEEDER_MNEMONIC", "")
+	t.Setenv("KEYRING_DIR", "")
+	t.Setenv("KEYSTORE_FILE", "")
+	t.Setenv("PASSPHRASE_FILE", "")
+	t.Setenv("HD_PATH", "")
+	t.Setenv("COIN_TYPE", "")
+	t.Setenv("REMOTE_SIGNER_URL", "")
+	t.Setenv("REMOTE_SIGNER_TLS_CERT", "")
+	t.Setenv("REMOTE_SIGNER_TLS_KEY", "")
+	t.Setenv("REMOTE_SIGNER_TLS_CA", "")
+
+	t.Run("keyring backend", func(t *testing.T) {
+		t.Setenv("KEYRING_BACKEND", "file")
+		t.Setenv("KEY_NAME", "feeder")
+		cfg, err := Get("")
+		require.NoError(t, err)
+		require.Equal(t, "file", cfg.KeyringBackend)
+		require.Equal(t, "feeder", cfg.KeyName)
+	})
+
+	t.Run("coin type", func(t *testing.T) {
+		t.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+		cfg, err := Get("")
+		require.NoError(t, err)
+		require.Equal(t, "m/44'/118'/0'/0/0", cfg.HDPath)
+
+		t.Setenv("COIN_TYPE", "60")
+		cfg, err = Get("")
+		require.NoError(t, err)
+		require.Equal(t, "m/44'/60'/0'/0/0", cfg.HDPath)
+
+		t.Setenv("HD_PATH", "m/44'/60'/0'/0/1")
+		cfg, err = Get("")
+		require.NoError(t, err)
+		require.Equal(t, "m/44'/60'/0'/0/1", cfg.HDPath)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+		t.Setenv("KEYRING_BACKEND", "kwallet")
+		t.Setenv("KEY_NAME", "")
+		t.Setenv("KEYSTORE_FILE", "keystore.json")
+		t.Setenv("HD_PATH", "m/44'")
+
+		_, err := Get("")
+		var errs Errors
+		require.ErrorAs(t, err, &errs)
+		expected := []string{
+			"keyring_backend: conflicts with feeder_mnemonic",
+			"keystore_file: conflicts with feeder_mnemonic",
+			"keyring_backend: unsupported keyring backend",
+			"passphrase_file: missing",
+			"hd_path: ",
+		}
+		require.Len(t, errs, len(expected))
+		for _, msg := range expected {
+			require.ErrorContains(t, err, msg)
+		}
+	})
+}
+
+func TestConfig_RemoteSigner(t *testing.T) {
+	t.Setenv("CHAIN_ID", "vsc-localnet-0")
+	t.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	t.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	t.Setenv("VALIDATOR_ADDRESS", "")
+	t.Setenv("EXCHANGE_SYMBOLS_MAP", "")
+	t.Setenv("DATASOURCE_CONFIG_MAP", "")
+	t.Setenv("AGGREGATION_CONFIG_MAP", "")
+	t.Setenv("DEVIATION_GUARD_CONFIG", "")
+	t.Setenv("FEEDER_MNEMONIC", "")
+	t.Setenv("KEYRING_BACKEND", "")
+	t.Setenv("KEYSTORE_FILE", "")
+	t.Setenv("HD_PATH", "")
+	t.Setenv("COIN_TYPE", "")
+	t.Setenv("REMOTE_SIGNER_URL", "https://signer.internal:8443")
+	t.Setenv("REMOTE_SIGNER_TLS_CERT", "/run/secrets/feeder.crt")
+	t.Setenv("REMOTE_SIGNER_TLS_KEY", "/run/secrets/feeder.key")
+	t.Setenv("REMOTE_SIGNER_TLS_CA", "/run/secrets/ca.crt")
+
+	t.Run("mutual tls", func(t *testing.T) {
+		cfg, err := Get("")
+		require.NoError(t, err)
+		require.Equal(t, "https://signer.internal:8443", cfg.RemoteSignerURL)
+	})
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected []string
+	}{
+		{
+			name:     "plain http",
+			env:      map[string]string{"REMOTE_SIGNER_URL": "http://signer.internal:8080"},
+			expected: []string{`remote_signer_url: invalid scheme "http", expected https`},
+		},
+		{
+			name:     "no client certificate",
+			env:      map[string]string{"REMOTE_SIGNER_TLS_CERT": "", "REMOTE_SIGNER_TLS_KEY": ""},
+			expected: []string{"remote_signer_tls_cert: missing", "remote_signer_tls_key: missing"},
+		},
+		{
+			name:     "no client key",
+			env:      map[string]string{"REMOTE_SIGNER_TLS_KEY": ""},
+			expected: []string{"remote_signer_tls_key: missing"},
+		},
+		{
+			name:     "no CA certificate",
+			env:      map[string]string{"REMOTE_SIGNER_TLS_CA": ""},
+			expected: []string{"remote_signer_tls_ca: missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for env, value := range tt.env {
+				t.Setenv(env, value)
+			}
+
+			_, err := Get("")
+			var errs Errors
+			require.ErrorAs(t, err, &errs)
+			require.Len(t, errs, len(tt.expected))
+			for _, msg := range tt.expected {
+				require.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestConfig_FEE_CONFIG(t *testing.T) {
+	t.Setenv("CHAIN_ID", "vsc-localnet-0")
+	t.Setenv("GRPC_ENDPOINT", "localhost:9090")
+	t.Setenv("WEBSOCKET_ENDPOINT", "ws://localhost:26657/websocket")
+	t.Setenv("FEEDER_MNEMONIC", "earth wash broom grow recall fitness")
+	t.Setenv("VALIDATOR_ADDRESS", "")
+
+	t.Setenv("FEE_CONFIG", "")
+	cfg, err := Get("")
+	require.NoError(t, err)
+	require.Equal(t, priceposter.DefaultFeeConfig, cfg.Fees)
+
+	t.Setenv("FEE_CONFIG", `{"gas_price": "0.025", "simulate": true, "max_gas_price": "0.1"}`)
+	cfg, err = Get("")
+	require.NoError(t, err)
+	require.Equal(t, priceposter.FeeConfig{
+		Denom:         "avsg",
+		GasPrice:      sdkmath.LegacyMustNewDecFromStr("0.025"),
+		GasLimit:      500_000,
+		Simulate:      true,
+		GasAdjustment: 1.5,
+		MaxGasPrice:   sdkmath.LegacyMustNewDecFromStr("0.1"),
+	}, cfg.Fees)
+
+	t.Setenv("FEE_CONFIG", `{"gas_price": "cheap", "gas_adjustment": 0.5}`)
+	_, err = Get("")
+	require.ErrorContains(t, err, "fee_config.gas_price")
+	require.ErrorContains(t, err, "fee_config.gas_adjustment: must be at least 1")
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errMissing       = errors.New("missing")
+	errNegative      = errors.New("negative")
+	errUnknownSource = errors.New("unknown source")
+)
+
+// Errors lists every problem found in the configuration,
+// each prefixed with the path of the offending key.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap allows errors.Is and errors.As to match any of the problems.
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Err returns the Errors, or nil if there are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// add records the problem at the path made of the given keys.
+func (e *Errors) add(err error, path ...string) {
+	*e = append(*e, fmt.Errorf("%s: %w", strings.Join(path, "."), err))
+}
+
+// parseEnv decodes the JSON environment variable into v, and reports whether it was set and valid.
+func (e *Errors) parseEnv(env string, v interface{}) bool {
+	value := os.Getenv(env)
+	if value == "" {
+		return false
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		e.add(fmt.Errorf("invalid json: %w", err), env)
+		return false
+	}
+	return true
+}
+
+// loadFile decodes the YAML or TOML config file, depending on its extension, into c.
+// The file is converted to JSON first, so that both formats share the schema of
+// the JSON environment variables, and unknown keys are rejected.
+func loadFile(path string, c *fileConfig) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	case ".toml":
+		err = toml.Unmarshal(b, &raw)
+	default:
+		return fmt.Errorf("unsupported extension %q, expected .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	jsonConfig, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonConfig))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(c)
+}
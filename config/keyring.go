@@ -3,6 +3,9 @@ package config
 import (
 	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
@@ -10,6 +13,9 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	"github.com/cosmos/go-bip39"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/vsc-blockchain/core/app"
 	"github.com/vsc-blockchain/core/crypto/ethsecp256k1"
 )
 
@@ -26,22 +32,97 @@ type privKeyKeyring struct {
 	pubKey  cryptotypes.PubKey
 	privKey cryptotypes.PrivKey
 
-	ImporterNull
-	MigratorNull
+	KeyringNull
+}
+
+// GetAuth returns the keyring holding the feeder key of the config, which is
+// loaded from a keyring backend, an encrypted keystore, a remote signer or derived
+// from the mnemonic, along with the feeder address and its validator address.
+func GetAuth(c *Config) (keyring.Keyring, sdk.ValAddress, sdk.AccAddress, error) {
+	switch {
+	case c.RemoteSignerURL != "":
+		return getRemoteSignerAuth(c)
+	case c.KeyringBackend != "":
+		return getKeyringAuth(c)
+	case c.KeystoreFile != "":
+		return getKeystoreAuth(c)
+	default:
+		return getMnemonicAuth(c.FeederMnemonic, c.HDPath)
+	}
 }
 
-func GetAuth(mnemonic string) (keyring.Keyring, sdk.ValAddress, sdk.AccAddress) {
+func getMnemonicAuth(mnemonic, hdPath string) (keyring.Keyring, sdk.ValAddress, sdk.AccAddress, error) {
 	seed := bip39.NewSeed(mnemonic, "")
 	master, ch := hd.ComputeMastersFromSeed(seed)
-	path := "m/44'/118'/0'/0/0"
 
-	priv, err := hd.DerivePrivateKeyForPath(master, ch, path)
+	priv, err := hd.DerivePrivateKeyForPath(master, ch, hdPath)
 	if err != nil {
-		panic(err)
+		return nil, nil, nil, fmt.Errorf("failed to derive key for path %s: %w", hdPath, err)
 	}
 	kr := newPrivKeyKeyring(hex.EncodeToString(priv))
 
-	return kr, sdk.ValAddress(kr.addr), kr.addr
+	return kr, sdk.ValAddress(kr.addr), kr.addr, nil
+}
+
+// getKeyringAuth opens the Cosmos SDK keyring backend, the passphrase of the file
+// backend is read from the passphrase file if any, or prompted otherwise.
+func getKeyringAuth(c *Config) (keyring.Keyring, sdk.ValAddress, sdk.AccAddress, error) {
+	var userInput io.Reader = os.Stdin
+	if c.PassphraseFile != "" {
+		passphrase, err := readPassphrase(c.PassphraseFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		userInput = strings.NewReader(passphrase + "\n")
+	}
+
+	encoding := app.MakeEncodingConfig()
+	encoding.InterfaceRegistry.RegisterImplementations((*cryptotypes.PubKey)(nil), &ethsecp256k1.PubKey{})
+	encoding.InterfaceRegistry.RegisterImplementations((*cryptotypes.PrivKey)(nil), &ethsecp256k1.PrivKey{})
+
+	kr, err := keyring.New(sdk.KeyringServiceName(), c.KeyringBackend, c.KeyringDir, userInput, encoding.Codec)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open %s keyring: %w", c.KeyringBackend, err)
+	}
+	record, err := kr.Key(c.KeyName)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get key %s: %w", c.KeyName, err)
+	}
+	addr, err := record.GetAddress()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return kr, sdk.ValAddress(addr), addr, nil
+}
+
+// getKeystoreAuth decrypts the Ethereum JSON keystore with the passphrase of the passphrase file.
+func getKeystoreAuth(c *Config) (keyring.Keyring, sdk.ValAddress, sdk.AccAddress, error) {
+	keyJSON, err := os.ReadFile(c.KeystoreFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	passphrase, err := readPassphrase(c.PassphraseFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to decrypt keystore: %w", err)
+	}
+	kr := newPrivKeyKeyring(hex.EncodeToString(ethcrypto.FromECDSA(key.PrivateKey)))
+
+	return kr, sdk.ValAddress(kr.addr), kr.addr, nil
+}
+
+// readPassphrase returns the first line of the passphrase file.
+func readPassphrase(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	passphrase, _, _ := strings.Cut(string(b), "\n")
+	return strings.TrimSuffix(passphrase, "\r"), nil
 }
 
 func newPrivKeyKeyring(hexKey string) *privKeyKeyring {
@@ -89,62 +170,68 @@ func (p privKeyKeyring) SignByAddress(address sdk.Address, msg []byte, signMode
 	return signed, p.pubKey, nil
 }
 
-// implementations that must never be called
+// KeyringNull implements the keyring.Keyring calls which are out of the scope of
+// Tx signing, which yield to panics. Embedding it along with the Key, KeyByAddress,
+// Sign and SignByAddress methods implements keyring.Keyring.
+type KeyringNull struct {
+	ImporterNull
+	MigratorNull
+}
 
-func (p privKeyKeyring) Backend() string {
+func (k KeyringNull) Backend() string {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) Rename(from string, to string) error {
+func (k KeyringNull) Rename(from string, to string) error {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) List() ([]*keyring.Record, error) {
+func (k KeyringNull) List() ([]*keyring.Record, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) SupportedAlgorithms() (keyring.SigningAlgoList, keyring.SigningAlgoList) {
+func (k KeyringNull) SupportedAlgorithms() (keyring.SigningAlgoList, keyring.SigningAlgoList) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) Delete(uid string) error {
+func (k KeyringNull) Delete(uid string) error {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) DeleteByAddress(address sdk.Address) error {
+func (k KeyringNull) DeleteByAddress(address sdk.Address) error {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) NewMnemonic(
+func (k KeyringNull) NewMnemonic(
 	uid string, language keyring.Language, hdPath, bip39Passphrase string, algo keyring.SignatureAlgo,
 ) (*keyring.Record, string, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) NewAccount(
+func (k KeyringNull) NewAccount(
 	uid, mnemonic, bip39Passphrase, hdPath string, algo keyring.SignatureAlgo,
 ) (*keyring.Record, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) SaveLedgerKey(
+func (k KeyringNull) SaveLedgerKey(
 	uid string, algo keyring.SignatureAlgo, hrp string, coinType, account, index uint32,
 ) (*keyring.Record, error) {
 	panic("must never be called")
 }
 
 // SaveOfflineKey stores a public key and returns the persisted Info structure.
-func (p privKeyKeyring) SaveOfflineKey(uid string, pubkey cryptotypes.PubKey) (*keyring.Record, error) {
+func (k KeyringNull) SaveOfflineKey(uid string, pubkey cryptotypes.PubKey) (*keyring.Record, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) SavePubKey(
+func (k KeyringNull) SavePubKey(
 	uid string, pubkey cryptotypes.PubKey, algo hd.PubKeyType,
 ) (*keyring.Record, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) SaveMultisig(uid string, pubkey cryptotypes.PubKey) (*keyring.Record, error) {
+func (k KeyringNull) SaveMultisig(uid string, pubkey cryptotypes.PubKey) (*keyring.Record, error) {
 	panic("must never be called")
 }
 
@@ -164,19 +251,19 @@ func (i ImporterNull) ImportPubKey(uid string, armor string) error {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) ExportPubKeyArmor(uid string) (string, error) {
+func (k KeyringNull) ExportPubKeyArmor(uid string) (string, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) ExportPubKeyArmorByAddress(address sdk.Address) (string, error) {
+func (k KeyringNull) ExportPubKeyArmorByAddress(address sdk.Address) (string, error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) ExportPrivKeyArmor(uid, encryptPassphrase string) (armor string, err error) {
+func (k KeyringNull) ExportPrivKeyArmor(uid, encryptPassphrase string) (armor string, err error) {
 	panic("must never be called")
 }
 
-func (p privKeyKeyring) ExportPrivKeyArmorByAddress(address sdk.Address, encryptPassphrase string) (armor string, err error) {
+func (k KeyringNull) ExportPrivKeyArmorByAddress(address sdk.Address, encryptPassphrase string) (armor string, err error) {
 	panic("must never be called")
 }
 
@@ -1,7 +1,100 @@
 package config
 
-import "testing"
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/app"
+)
+
+const testMnemonic = "guard cream sadness conduct invite crumble clock pudding hole grit liar hotel maid produce squeeze return argue turtle know drive eight casino maze host"
 
 func Test_privKeyKeyring(t *testing.T) {
 
 }
+
+func TestGetAuth_Mnemonic(t *testing.T) {
+	cosmosPath := hd.CreateHDPath(sdk.CoinType, 0, 0).String()
+	ethPath := hd.CreateHDPath(60, 0, 0).String()
+
+	kr, valAddr, feederAddr, err := GetAuth(&Config{FeederMnemonic: testMnemonic, HDPath: cosmosPath})
+	require.NoError(t, err)
+	require.Equal(t, sdk.ValAddress(feederAddr), valAddr)
+	_, err = kr.KeyByAddress(feederAddr)
+	require.NoError(t, err)
+
+	_, _, ethAddr, err := GetAuth(&Config{FeederMnemonic: testMnemonic, HDPath: ethPath})
+	require.NoError(t, err)
+	require.NotEqual(t, feederAddr, ethAddr)
+
+	_, _, _, err = GetAuth(&Config{FeederMnemonic: testMnemonic, HDPath: "m/44'/x'"})
+	require.Error(t, err)
+}
+
+func TestGetAuth_Keyring(t *testing.T) {
+	hdPath := hd.CreateHDPath(sdk.CoinType, 0, 0).String()
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(passphraseFile, []byte("12345678\n"), 0o600))
+
+	for _, backend := range []string{keyring.BackendTest, keyring.BackendFile} {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+			kr, err := keyring.New(sdk.KeyringServiceName(), backend, dir, strings.NewReader("12345678\n12345678\n"), app.MakeEncodingConfig().Codec)
+			require.NoError(t, err)
+			record, err := kr.NewAccount("feeder", testMnemonic, "", hdPath, hd.Secp256k1)
+			require.NoError(t, err)
+			keyringAddr, err := record.GetAddress()
+			require.NoError(t, err)
+
+			c := &Config{KeyringBackend: backend, KeyringDir: dir, KeyName: "feeder", PassphraseFile: passphraseFile}
+			kb, valAddr, feederAddr, err := GetAuth(c)
+			require.NoError(t, err)
+			require.Equal(t, keyringAddr, feederAddr)
+			require.Equal(t, sdk.ValAddress(keyringAddr), valAddr)
+
+			_, pubKey, err := kb.SignByAddress(feederAddr, []byte("msg"), signing.SignMode_SIGN_MODE_DIRECT)
+			require.NoError(t, err)
+			require.Equal(t, sdk.AccAddress(pubKey.Address()), feederAddr)
+
+			c.KeyName = "unknown"
+			_, _, _, err = GetAuth(c)
+			require.ErrorContains(t, err, "unknown")
+		})
+	}
+}
+
+func TestGetAuth_Keystore(t *testing.T) {
+	dir := t.TempDir()
+	privKey, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    ethcrypto.PubkeyToAddress(privKey.PublicKey),
+		PrivateKey: privKey,
+	}, "12345678", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+
+	keystoreFile := filepath.Join(dir, "keystore.json")
+	require.NoError(t, os.WriteFile(keystoreFile, keyJSON, 0o600))
+	passphraseFile := filepath.Join(dir, "passphrase")
+	require.NoError(t, os.WriteFile(passphraseFile, []byte("12345678\n"), 0o600))
+
+	_, _, feederAddr, err := GetAuth(&Config{KeystoreFile: keystoreFile, PassphraseFile: passphraseFile})
+	require.NoError(t, err)
+	require.Equal(t, newPrivKeyKeyring(hex.EncodeToString(ethcrypto.FromECDSA(privKey))).addr, feederAddr)
+
+	require.NoError(t, os.WriteFile(passphraseFile, []byte("wrong"), 0o600))
+	_, _, _, err = GetAuth(&Config{KeystoreFile: keystoreFile, PassphraseFile: passphraseFile})
+	require.ErrorContains(t, err, "failed to decrypt keystore")
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/vsc-blockchain/core/crypto/ethsecp256k1"
+)
+
+// RemoteSignerTimeout is the timeout of the requests to the remote signer.
+var RemoteSignerTimeout = 10 * time.Second
+
+var _ keyring.Keyring = (*remoteSignerKeyring)(nil)
+
+// remoteSignerKeyring partially implements the keyring.Keyring
+// interface like privKeyKeyring, but delegates the signing
+// to a remote signer so the private key never enters the process.
+//
+// The remote signer serves the feeder public key at GET /pubkey
+// and signs the messages POSTed to /sign, see remoteSignerSignRequest.
+type remoteSignerKeyring struct {
+	url    string
+	client *http.Client
+	addr   sdk.AccAddress
+	pubKey cryptotypes.PubKey
+
+	KeyringNull
+}
+
+// remoteSignerPubKeyResponse is the response of GET /pubkey, with the
+// base64 encoded compressed eth_secp256k1 public key of the feeder.
+type remoteSignerPubKeyResponse struct {
+	PubKey []byte `json:"pub_key"`
+}
+
+// remoteSignerSignRequest is the body of POST /sign, with the
+// base64 encoded bytes to sign.
+type remoteSignerSignRequest struct {
+	Address  string `json:"address"`
+	SignMode string `json:"sign_mode"`
+	Msg      []byte `json:"msg"`
+}
+
+// remoteSignerSignResponse is the response of POST /sign, with the base64 encoded signature.
+type remoteSignerSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+func getRemoteSignerAuth(c *Config) (keyring.Keyring, sdk.ValAddress, sdk.AccAddress, error) {
+	if err := c.validateRemoteSigner().Err(); err != nil {
+		return nil, nil, nil, err
+	}
+	tlsConfig, err := remoteSignerTLSConfig(c.RemoteSignerTLSCert, c.RemoteSignerTLSKey, c.RemoteSignerTLSCA)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	kr, err := newRemoteSignerKeyring(c.RemoteSignerURL, tlsConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return kr, sdk.ValAddress(kr.addr), kr.addr, nil
+}
+
+// validateRemoteSigner checks that the remote signer is reached over mutual TLS, as anyone
+// reaching it could otherwise sign with the feeder key, and the feeder be fed forged signatures.
+func (c *Config) validateRemoteSigner() Errors {
+	var errs Errors
+	if u, err := url.Parse(c.RemoteSignerURL); err != nil {
+		errs.add(err, "remote_signer_url")
+	} else if u.Scheme != "https" {
+		errs.add(fmt.Errorf("invalid scheme %q, expected https", u.Scheme), "remote_signer_url")
+	}
+	if c.RemoteSignerTLSCert == "" {
+		errs.add(errMissing, "remote_signer_tls_cert")
+	}
+	if c.RemoteSignerTLSKey == "" {
+		errs.add(errMissing, "remote_signer_tls_key")
+	}
+	if c.RemoteSignerTLSCA == "" {
+		errs.add(errMissing, "remote_signer_tls_ca")
+	}
+	return errs
+}
+
+// newRemoteSignerKeyring fetches the public key of the feeder from the remote signer.
+func newRemoteSignerKeyring(url string, tlsConfig *tls.Config) (*remoteSignerKeyring, error) {
+	r := &remoteSignerKeyring{
+		url: strings.TrimSuffix(url, "/"),
+		client: &http.Client{
+			Timeout:   RemoteSignerTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+
+	var resp remoteSignerPubKeyResponse
+	if err := r.do(http.MethodGet, "/pubkey", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get remote signer public key: %w", err)
+	}
+	if len(resp.PubKey) != ethsecp256k1.PubKeySize {
+		return nil, fmt.Errorf("invalid remote signer public key length: %d", len(resp.PubKey))
+	}
+	r.pubKey = &ethsecp256k1.PubKey{Key: resp.PubKey}
+	r.addr = sdk.AccAddress(r.pubKey.Address())
+
+	return r, nil
+}
+
+// remoteSignerTLSConfig returns the TLS config authenticating the feeder with the client certificate,
+// and the remote signer with the CA certificate, if any.
+func remoteSignerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load remote signer client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read remote signer CA certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("invalid remote signer CA certificate")
+		}
+	}
+	return tlsConfig, nil
+}
+
+func (r *remoteSignerKeyring) do(method, path string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		b, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, r.url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return json.Unmarshal(b, response)
+}
+
+func (r *remoteSignerKeyring) Key(uid string) (*keyring.Record, error) {
+	return r.KeyByAddress(r.addr)
+}
+
+func (r *remoteSignerKeyring) KeyByAddress(address sdk.Address) (*keyring.Record, error) {
+	if !address.Equals(r.addr) {
+		return nil, fmt.Errorf("key not found: %s", address)
+	}
+
+	return keyring.NewOfflineRecord(r.addr.String(), r.pubKey)
+}
+
+func (r *remoteSignerKeyring) Sign(uid string, msg []byte, signMode signing.SignMode) ([]byte, cryptotypes.PubKey, error) {
+	return r.SignByAddress(r.addr, msg, signMode)
+}
+
+// SignByAddress asks the remote signer to sign the message, and verifies
+// the signature before returning it.
+func (r *remoteSignerKeyring) SignByAddress(address sdk.Address, msg []byte, signMode signing.SignMode) ([]byte, cryptotypes.PubKey, error) {
+	if !r.addr.Equals(address) {
+		return nil, nil, fmt.Errorf("key not found")
+	}
+
+	var resp remoteSignerSignResponse
+	err := r.do(http.MethodPost, "/sign", remoteSignerSignRequest{
+		Address:  r.addr.String(),
+		SignMode: signMode.String(),
+		Msg:      msg,
+	}, &resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("remote signer failed to sign: %w", err)
+	}
+	if !r.pubKey.VerifySignature(msg, resp.Signature) {
+		return nil, nil, fmt.Errorf("invalid remote signer signature")
+	}
+
+	return resp.Signature, r.pubKey, nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/crypto/ethsecp256k1"
+)
+
+// remoteSigner is a local stand-in for a remote signing service.
+type remoteSigner struct {
+	privKey *ethsecp256k1.PrivKey
+	tamper  bool // return invalid signatures
+	signed  []remoteSignerSignRequest
+}
+
+func (s *remoteSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/pubkey":
+		_ = json.NewEncoder(w).Encode(remoteSignerPubKeyResponse{PubKey: s.privKey.PubKey().Bytes()})
+	case r.Method == http.MethodPost && r.URL.Path == "/sign":
+		var req remoteSignerSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.signed = append(s.signed, req)
+		signature, err := s.privKey.Sign(req.Msg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if s.tamper {
+			signature[0] ^= 0xff
+		}
+		_ = json.NewEncoder(w).Encode(remoteSignerSignResponse{Signature: signature})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// startRemoteSigner serves the signer over mutual TLS, and returns the config of a feeder
+// authenticated by a client certificate, both issued by the same test CA.
+func startRemoteSigner(t *testing.T, signer *remoteSigner) *Config {
+	dir := t.TempDir()
+	caCert, caKey := newTestCertificate(t, nil, nil, dir, "ca")
+	newTestCertificate(t, caCert, caKey, dir, "server")
+	newTestCertificate(t, caCert, caKey, dir, "client")
+
+	serverCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+
+	server := httptest.NewUnstartedServer(signer)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return &Config{
+		RemoteSignerURL:     server.URL + "/",
+		RemoteSignerTLSCert: filepath.Join(dir, "client.crt"),
+		RemoteSignerTLSKey:  filepath.Join(dir, "client.key"),
+		RemoteSignerTLSCA:   filepath.Join(dir, "ca.crt"),
+	}
+}
+
+func TestRemoteSignerKeyring(t *testing.T) {
+	signer := &remoteSigner{privKey: &ethsecp256k1.PrivKey{Key: make([]byte, 32)}}
+	signer.privKey.Key[31] = 1
+
+	kr, valAddr, feederAddr, err := GetAuth(startRemoteSigner(t, signer))
+	require.NoError(t, err)
+	require.Equal(t, sdk.AccAddress(signer.privKey.PubKey().Address()), feederAddr)
+	require.Equal(t, sdk.ValAddress(feederAddr), valAddr)
+
+	record, err := kr.KeyByAddress(feederAddr)
+	require.NoError(t, err)
+	pubKey, err := record.GetPubKey()
+	require.NoError(t, err)
+	require.True(t, pubKey.Equals(signer.privKey.PubKey()))
+
+	signature, pubKey, err := kr.Sign(record.Name, []byte("msg"), signing.SignMode_SIGN_MODE_DIRECT)
+	require.NoError(t, err)
+	require.True(t, pubKey.VerifySignature([]byte("msg"), signature))
+	require.Equal(t, []remoteSignerSignRequest{{
+		Address:  feederAddr.String(),
+		SignMode: signing.SignMode_SIGN_MODE_DIRECT.String(),
+		Msg:      []byte("msg"),
+	}}, signer.signed)
+
+	_, _, err = kr.SignByAddress(sdk.AccAddress(make([]byte, 20)), []byte("msg"), signing.SignMode_SIGN_MODE_DIRECT)
+	require.Error(t, err)
+
+	signer.tamper = true
+	_, _, err = kr.Sign(record.Name, []byte("msg"), signing.SignMode_SIGN_MODE_DIRECT)
+	require.ErrorContains(t, err, "invalid remote signer signature")
+}
+
+func TestRemoteSignerKeyring_MutualTLS(t *testing.T) {
+	signer := &remoteSigner{privKey: &ethsecp256k1.PrivKey{Key: make([]byte, 32)}}
+	signer.privKey.Key[31] = 1
+	c := startRemoteSigner(t, signer)
+
+	// the signer rejects feeders whose client certificate was issued by another CA
+	dir := t.TempDir()
+	otherCA, otherCAKey := newTestCertificate(t, nil, nil, dir, "ca")
+	newTestCertificate(t, otherCA, otherCAKey, dir, "client")
+	untrusted := *c
+	untrusted.RemoteSignerTLSCert = filepath.Join(dir, "client.crt")
+	untrusted.RemoteSignerTLSKey = filepath.Join(dir, "client.key")
+	_, _, _, err := GetAuth(&untrusted)
+	require.Error(t, err)
+
+	// and the feeder rejects signers whose certificate was issued by another CA
+	untrusted = *c
+	untrusted.RemoteSignerTLSCA = filepath.Join(dir, "ca.crt")
+	_, _, _, err = GetAuth(&untrusted)
+	require.Error(t, err)
+
+	// and a remote signer without mutual TLS is never reached
+	_, _, _, err = GetAuth(&Config{RemoteSignerURL: "http://127.0.0.1:1"})
+	require.ErrorContains(t, err, "remote_signer_url: invalid scheme")
+	require.Empty(t, signer.signed)
+}
+
+// newTestCertificate writes <name>.crt and <name>.key to dir, signed by the parent
+// certificate, or self-signed as a CA when parent is nil.
+func newTestCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, dir, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return cert, key
+}
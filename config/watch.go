@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// WatchDebounce defines how long the Watcher waits for the config
+// file to stop changing before reloading it.
+var WatchDebounce = 1 * time.Second
+
+// Watcher reloads the configuration whenever the config file changes
+// or the process receives a SIGHUP.
+type Watcher struct {
+	logger     zerolog.Logger
+	configFile string
+	onReload   func(*Config)
+	watcher    *fsnotify.Watcher
+	sighup     chan os.Signal
+	stopSignal chan struct{} // external signal to stop the loop
+	done       chan struct{} // internal signal to wait for shutdown operations
+}
+
+// NewWatcher starts watching the config file, which may be empty to only reload on SIGHUP,
+// and calls onReload with every new valid configuration. Invalid configurations are
+// logged and skipped, so that the current one remains in use.
+func NewWatcher(configFile string, logger zerolog.Logger, onReload func(*Config)) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// the directory is watched, as editors and config mounts
+	// usually replace the file instead of writing it.
+	if configFile != "" {
+		if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	w := &Watcher{
+		logger:     logger.With().Str("component", "config-watcher").Logger(),
+		configFile: configFile,
+		onReload:   onReload,
+		watcher:    watcher,
+		sighup:     make(chan os.Signal, 1),
+		stopSignal: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	signal.Notify(w.sighup, syscall.SIGHUP)
+
+	go w.loop()
+
+	return w, nil
+}
+
+func (w *Watcher) loop() {
+	defer close(w.done)
+	defer signal.Stop(w.sighup)
+	defer w.watcher.Close()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-w.stopSignal:
+			return
+		case <-w.sighup:
+			w.logger.Info().Msg("received SIGHUP, reloading config")
+			w.reload()
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.affectsConfigFile(event) {
+				debounce = time.After(WatchDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			w.logger.Info().Str("file", w.configFile).Msg("config file changed, reloading config")
+			w.reload()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Err(err).Msg("config watcher error")
+		}
+	}
+}
+
+// affectsConfigFile reports whether the event may have changed the content of the config file,
+// including the swap of the "..data" symlink through which Kubernetes updates mounted configs.
+func (w *Watcher) affectsConfigFile(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	return filepath.Clean(event.Name) == filepath.Clean(w.configFile) || filepath.Base(event.Name) == "..data"
+}
+
+func (w *Watcher) reload() {
+	c, err := Get(w.configFile)
+	if err != nil {
+		w.logger.Error().Err(err).Msg("invalid config, keeping the current one")
+		return
+	}
+	w.onReload(c)
+}
+
+func (w *Watcher) Close() {
+	close(w.stopSignal)
+	<-w.done
+}
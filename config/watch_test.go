@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/sources"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestWatcher(t *testing.T) {
+	for _, env := range []string{"CHAIN_ID", "GRPC_ENDPOINT", "WEBSOCKET_ENDPOINT", "FEEDER_MNEMONIC", "VALIDATOR_ADDRESS",
+		"EXCHANGE_SYMBOLS_MAP", "DATASOURCE_CONFIG_MAP", "AGGREGATION_CONFIG_MAP", "DEVIATION_GUARD_CONFIG"} {
+		t.Setenv(env, "")
+	}
+	WatchDebounce = 10 * time.Millisecond
+	defer func() { WatchDebounce = 1 * time.Second }()
+
+	base := `
+chain_id: vsc-localnet-0
+grpc_endpoint: localhost:9090
+websocket_endpoint: ws://localhost:26657/websocket
+feeder_mnemonic: earth wash broom grow recall fitness
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(base), 0o600))
+
+	reloads := make(chan *Config, 10)
+	w, err := NewWatcher(path, zerolog.Nop(), func(c *Config) { reloads <- c })
+	require.NoError(t, err)
+	defer w.Close()
+
+	receive := func() *Config {
+		select {
+		case c := <-reloads:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout when waiting for reload")
+			return nil
+		}
+	}
+
+	t.Run("file change", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(base+`
+exchange_symbols_map:
+  binance:
+    ubtc:uusd: BTCUSDT
+`), 0o600))
+		c := receive()
+		require.Equal(t, map[asset.Pair]types.Symbol{asset.MustNewPair("ubtc:uusd"): "BTCUSDT"}, c.ExchangesToPairToSymbolMap[sources.Binance])
+	})
+
+	t.Run("invalid config is skipped", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(base+`
+exchange_symbols_map:
+  binancee:
+    ubtc:uusd: BTCUSDT
+`), 0o600))
+		select {
+		case <-reloads:
+			t.Fatal("invalid config reloaded")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+
+	t.Run("sighup", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(base), 0o600))
+		_ = receive()
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		c := receive()
+		require.Equal(t, "vsc-localnet-0", c.ChainID)
+	})
+}
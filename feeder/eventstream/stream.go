@@ -2,68 +2,78 @@ package eventstream
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	abcitypes "github.com/cometbft/cometbft/abci/types"
 	"github.com/rs/zerolog"
 	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/feeder/failover"
 	"github.com/vsc-blockchain/pricefeeder/types"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 )
 
 var _ types.EventStream = (*Stream)(nil)
 
+// ParamsChangeEvents are the types of the block events after which the params are queried again,
+// the oracle params being changed by governance proposals.
+var ParamsChangeEvents = []string{"active_proposal"}
+
+// paramsRetryDelay is the delay between two queries of the initial params.
+var paramsRetryDelay = 1 * time.Second
+
 // wsI exists for testing purposes.
 type wsI interface {
 	message() <-chan []byte
+	connected() <-chan bool
+	reconnect()
 	close()
 }
 
-// Dial opens two connections to the given endpoint, one for the websocket and one for the oracle grpc.
-func Dial(tendermintRPCEndpoint string, grpcEndpoint string, enableTLS bool, logger zerolog.Logger) *Stream {
-	transportDialOpt := grpc.WithInsecure()
-
-	if enableTLS {
-		transportDialOpt = grpc.WithTransportCredentials(
-			credentials.NewTLS(
-				&tls.Config{
-					InsecureSkipVerify: false,
-				},
-			),
-		)
-	}
-
-	conn, err := grpc.Dial(grpcEndpoint, transportDialOpt)
-	if err != nil {
-		panic(err)
-	}
-
+// Dial connects to the websocket endpoints, to be notified of the new blocks, and to the grpc endpoints,
+// to query the oracle params, keeping the connections to the healthiest endpoints. The voting periods
+// start after the blocks emitting an event of type votePeriodEvent, if set, or every vote period blocks.
+// The params are queried at start, after the blocks emitting ParamsChangeEvents, and every paramsPollInterval.
+func Dial(websocketEndpoints []string, grpcEndpoints []string, enableTLS bool, votePeriodEvent string, paramsPollInterval time.Duration, logger zerolog.Logger) *Stream {
+	conn, err := failover.DialGRPC("eventstream_grpc", grpcEndpoints, enableTLS, logger)
 	if err != nil {
 		panic(err)
 	}
 	oracleClient := oracletypes.NewQueryClient(conn)
 
-	const newBlockSubscribe = `{"jsonrpc":"2.0","method":"subscribe","id":0,"params":{"query":"tm.event='NewBlock'"}}`
-	ws := NewWebsocket(tendermintRPCEndpoint, []byte(newBlockSubscribe), logger)
-	return newStream(ws, oracleClient, logger)
+	pool := failover.NewPool("eventstream_websocket", websocketEndpoints, failover.CheckWebsocket, logger)
+	newBlockEventsSubscribe := fmt.Sprintf(`{"jsonrpc":"2.0","method":"subscribe","id":0,"params":{"query":%q}}`, types.NewBlockEventsQuery)
+	ws := newWebsocket(pool.Active, []byte(newBlockEventsSubscribe), logger)
+	pool.OnFailover(func(string) { ws.reconnect() })
+
+	stream := newStream(ws, oracleClient, votePeriodEvent, paramsPollInterval, logger)
+	stream.onClose = func() {
+		pool.Close()
+		if err := conn.Close(); err != nil {
+			logger.Err(err).Msg("close error")
+		}
+	}
+	return stream
 }
 
-func newStream(ws wsI, oracle oracletypes.QueryClient, logger zerolog.Logger) *Stream {
+func newStream(ws wsI, oracle oracletypes.QueryClient, votePeriodEvent string, paramsPollInterval time.Duration, logger zerolog.Logger) *Stream {
 	stream := &Stream{
 		stopSignal:          make(chan struct{}),
 		waitGroup:           new(sync.WaitGroup),
 		votingPeriodChannel: make(chan types.VotingPeriod),
 		paramsChannel:       make(chan types.Params, 1),
+		paramsRefresh:       make(chan struct{}, 1),
 		params:              new(atomic.Pointer[types.Params]),
+		connected:           ws.connected(),
+		votePeriodEvent:     votePeriodEvent,
 	}
 
 	stream.waitGroup.Add(2)
 
 	go stream.votingPeriodStartedLoop(ws, logger.With().Str("component", "voting-period-started-loop").Logger())
-	go stream.paramsLoop(oracle, logger.With().Str("component", "params-loop").Logger())
+	go stream.paramsLoop(oracle, paramsPollInterval, logger.With().Str("component", "params-loop").Logger())
 
 	return stream
 }
@@ -73,7 +83,11 @@ type Stream struct {
 	waitGroup           *sync.WaitGroup
 	votingPeriodChannel chan types.VotingPeriod
 	paramsChannel       chan types.Params
+	paramsRefresh       chan struct{} // signal to query the params before the next poll
 	params              *atomic.Pointer[types.Params]
+	connected           <-chan bool
+	votePeriodEvent     string // type of the oracle event ending a voting period, if used
+	onClose             func() // releases the connections once the loops exited
 }
 
 func (s *Stream) votingPeriodStartedLoop(ws wsI, logger zerolog.Logger) {
@@ -83,43 +97,75 @@ func (s *Stream) votingPeriodStartedLoop(ws wsI, logger zerolog.Logger) {
 		ws.close()
 	}()
 
+	tracker := &votingPeriodTracker{byEvents: s.votePeriodEvent != ""}
 	for {
 		select {
 		case <-s.stopSignal:
 			return
 		case msg := <-ws.message():
 			logger.Debug().Bytes("payload", msg).Msg("received message from websocket")
-			blockHeight, err := types.GetBlockHeight(msg)
+			block, err := types.ParseNewBlockEvents(msg)
+			if errors.Is(err, types.ErrSubscription) {
+				// the subscription is lost, e.g. when the node cancels it, and is renewed by reconnecting
+				logger.Err(err).Msg("subscription failed, resubscribing")
+				ws.reconnect()
+				break
+			}
 			if err != nil {
-				logger.Err(err).Msg("could not obtain block height")
+				logger.Err(err).Msg("could not decode block events")
+				break
+			}
+			if block == nil {
+				logger.Debug().Msg("subscribed to block events")
 				break
 			}
-			if blockHeight <= 0 {
-				logger.Err(err).Uint64("block-height", blockHeight).Msg("invalid block height")
+			if block.Height <= 0 {
+				logger.Error().Int64("block-height", block.Height).Msg("invalid block height")
 				break
 			}
+			blockHeight := uint64(block.Height)
+			s.refreshParamsOnChange(block.Events, logger)
 			p := s.params.Load()
-			if p == nil {
+			if p == nil || p.VotePeriodBlocks == 0 {
 				break
 			}
-			if (blockHeight+1)%p.VotePeriodBlocks != 0 {
+			periodEnded := s.votePeriodEvent != "" && types.HasEvent(block.Events, s.votePeriodEvent)
+			vp, ok := tracker.next(blockHeight, p.VotePeriodBlocks, periodEnded)
+			if !ok {
 				break
 			}
+			if vp.CatchUp {
+				logger.Warn().Uint64("height", vp.Height).Uint64("block-height", blockHeight).Msg("catching up on a voting period whose first block was missed")
+			}
 
 			logger.Debug().Msg("signaling new voting period")
 			select {
 			case <-s.stopSignal:
-				logger.Warn().Uint64("height", blockHeight+1).Msg("dropped voting period signal")
-			case s.votingPeriodChannel <- types.VotingPeriod{Height: blockHeight + 1}:
+				logger.Warn().Uint64("height", vp.Height).Msg("dropped voting period signal")
+			case s.votingPeriodChannel <- vp:
 				logger.Debug().Msg("signaled new voting period")
 			}
 		}
 	}
 }
 
-// paramsLoop calls every 10 seconds the oracle grpc to obtain the current params as a way to keep the params up to date.
-func (s *Stream) paramsLoop(oracleClient oracletypes.QueryClient, logger zerolog.Logger) {
-	tick := time.NewTicker(10 * time.Second)
+// refreshParamsOnChange signals the params loop to query the params if the block emitted ParamsChangeEvents.
+func (s *Stream) refreshParamsOnChange(events []abcitypes.Event, logger zerolog.Logger) {
+	if !types.HasEvent(events, ParamsChangeEvents...) {
+		return
+	}
+	select {
+	case s.paramsRefresh <- struct{}{}:
+		logger.Debug().Msg("signaled params refresh")
+	default:
+		// a refresh is already pending
+	}
+}
+
+// paramsLoop queries the oracle params at start, whenever a refresh is signaled, and every pollInterval
+// as a fallback, to keep the params up to date.
+func (s *Stream) paramsLoop(oracleClient oracletypes.QueryClient, pollInterval time.Duration, logger zerolog.Logger) {
+	tick := time.NewTicker(pollInterval)
 	defer func() {
 		logger.Info().Msg("exited loop")
 		s.waitGroup.Done()
@@ -138,28 +184,43 @@ func (s *Stream) paramsLoop(oracleClient oracletypes.QueryClient, logger zerolog
 		return types.ParamsFromOracleParams(paramsResp.Params), nil
 	}
 
-	for {
-		select {
-		case <-tick.C:
-			newParams, err := fetchParams()
-			if err != nil {
-				logger.Err(err).Msg("param update failed")
-				break
-			}
+	updateParams := func() bool {
+		newParams, err := fetchParams()
+		if err != nil {
+			logger.Err(err).Msg("param update failed")
+			return false
+		}
 
-			oldParams := s.params.Swap(&newParams)
-			if oldParams != nil && oldParams.Equal(newParams) {
-				logger.Debug().Msg("skipping params update as they're not different from the old ones")
-				break
-			}
+		oldParams := s.params.Swap(&newParams)
+		if oldParams != nil && oldParams.Equal(newParams) {
+			logger.Debug().Msg("skipping params update as they're not different from the old ones")
+			return true
+		}
 
-			select {
-			case <-s.stopSignal:
-				logger.Warn().Msg("dropped params update due to shutdown")
-			case s.paramsChannel <- newParams:
-				logger.Info().Interface("params", newParams).Msg("signaling new params update")
-			}
+		select {
+		case <-s.stopSignal:
+			logger.Warn().Msg("dropped params update due to shutdown")
+		case s.paramsChannel <- newParams:
+			logger.Info().Interface("params", newParams).Msg("signaling new params update")
+		}
+		return true
+	}
 
+	// the initial params are retried without waiting for the next poll
+	for !updateParams() {
+		select {
+		case <-time.After(paramsRetryDelay):
+		case <-s.stopSignal:
+			return
+		}
+	}
+	for {
+		select {
+		case <-tick.C:
+			updateParams()
+		case <-s.paramsRefresh:
+			logger.Info().Msg("refreshing params after a governance proposal")
+			updateParams()
 		case <-s.stopSignal:
 			return
 		}
@@ -169,6 +230,9 @@ func (s *Stream) paramsLoop(oracleClient oracletypes.QueryClient, logger zerolog
 func (s *Stream) Close() {
 	close(s.stopSignal)
 	s.waitGroup.Wait()
+	if s.onClose != nil {
+		s.onClose()
+	}
 }
 
 func (s *Stream) ParamsUpdate() <-chan types.Params {
@@ -178,3 +242,7 @@ func (s *Stream) ParamsUpdate() <-chan types.Params {
 func (s *Stream) VotingPeriodStarted() <-chan types.VotingPeriod {
 	return s.votingPeriodChannel
 }
+
+func (s *Stream) Connected() <-chan bool {
+	return s.connected
+}
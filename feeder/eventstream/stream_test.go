@@ -2,7 +2,11 @@ package eventstream
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -10,9 +14,12 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/vsc-blockchain/core/app"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	"github.com/vsc-blockchain/core/x/common/denoms"
 	testutilcli "github.com/vsc-blockchain/core/x/common/testutil/cli"
 	"github.com/vsc-blockchain/core/x/common/testutil/genesis"
 	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/types"
 	"github.com/vsc-blockchain/pricefeeder/utils"
 	"google.golang.org/grpc"
 )
@@ -53,9 +60,11 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	s.logs = new(bytes.Buffer)
 	enableTLS := false
 	s.eventStream = Dial(
-		u.String(),
-		grpcEndpoint,
+		[]string{u.String()},
+		[]string{grpcEndpoint},
 		enableTLS,
+		"",
+		5*time.Second,
 		zerolog.New(s.logs))
 
 	conn, err := grpc.Dial(grpcEndpoint, grpc.WithInsecure())
@@ -89,3 +98,83 @@ func (s *IntegrationTestSuite) TearDownSuite() {
 func TestIntegration(t *testing.T) {
 	suite.Run(t, new(IntegrationTestSuite))
 }
+
+type fakeWebsocket struct {
+	messages   chan []byte
+	health     chan bool
+	reconnects chan struct{}
+}
+
+func (f fakeWebsocket) message() <-chan []byte { return f.messages }
+func (f fakeWebsocket) connected() <-chan bool { return f.health }
+func (f fakeWebsocket) reconnect()             { f.reconnects <- struct{}{} }
+func (f fakeWebsocket) close()                 {}
+
+func newFakeWebsocket() fakeWebsocket {
+	return fakeWebsocket{messages: make(chan []byte), health: make(chan bool), reconnects: make(chan struct{}, 1)}
+}
+
+type fakeOracle struct {
+	oracletypes.QueryClient
+	params func() (oracletypes.Params, error)
+}
+
+func (f fakeOracle) Params(context.Context, *oracletypes.QueryParamsRequest, ...grpc.CallOption) (*oracletypes.QueryParamsResponse, error) {
+	params, err := f.params()
+	if err != nil {
+		return nil, err
+	}
+	return &oracletypes.QueryParamsResponse{Params: params}, nil
+}
+
+func TestStreamParams(t *testing.T) {
+	defer func(delay time.Duration) { paramsRetryDelay = delay }(paramsRetryDelay)
+	paramsRetryDelay = time.Millisecond
+
+	pairs := new(atomic.Pointer[[]asset.Pair])
+	pairs.Store(&[]asset.Pair{asset.Registry.Pair(denoms.BTC, denoms.NUSD)})
+	failures := new(atomic.Int32)
+	oracle := fakeOracle{params: func() (oracletypes.Params, error) {
+		// the first query fails
+		if failures.Add(1) == 1 {
+			return oracletypes.Params{}, errors.New("unavailable")
+		}
+		return oracletypes.Params{VotePeriod: 10, Whitelist: *pairs.Load()}, nil
+	}}
+	ws := newFakeWebsocket()
+
+	// the params are queried at start, without waiting for the poll interval
+	stream := newStream(ws, oracle, "", time.Hour, zerolog.New(io.Discard))
+	defer stream.Close()
+	requireParams := func(want types.Params) {
+		t.Helper()
+		select {
+		case params := <-stream.ParamsUpdate():
+			require.Equal(t, want, params)
+		case <-time.After(5 * time.Second):
+			t.Fatal("params timeout")
+		}
+	}
+	requireParams(types.Params{Pairs: []asset.Pair{asset.Registry.Pair(denoms.BTC, denoms.NUSD)}, VotePeriodBlocks: 10})
+
+	// the params are queried again after a governance proposal
+	pairs.Store(&[]asset.Pair{asset.Registry.Pair(denoms.BTC, denoms.NUSD), asset.Registry.Pair(denoms.ETH, denoms.NUSD)})
+	ws.messages <- []byte(`{"jsonrpc":"2.0","id":0,"result":{"query":"tm.event='NewBlockEvents'","data":{"type":"tendermint/event/NewBlockEvents","value":{"height":"5","events":[{"type":"active_proposal","attributes":[]}],"num_txs":"0"}}}}`)
+	requireParams(types.Params{Pairs: []asset.Pair{asset.Registry.Pair(denoms.BTC, denoms.NUSD), asset.Registry.Pair(denoms.ETH, denoms.NUSD)}, VotePeriodBlocks: 10})
+}
+
+func TestStreamResubscribes(t *testing.T) {
+	oracle := fakeOracle{params: func() (oracletypes.Params, error) {
+		return oracletypes.Params{VotePeriod: 10}, nil
+	}}
+	ws := newFakeWebsocket()
+	stream := newStream(ws, oracle, "", time.Hour, zerolog.New(io.Discard))
+	defer stream.Close()
+
+	ws.messages <- []byte(`{"jsonrpc":"2.0","id":0,"error":{"code":-32000,"message":"Server error","data":"subscription was canceled"}}`)
+	select {
+	case <-ws.reconnects:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reconnection")
+	}
+}
@@ -0,0 +1,46 @@
+package eventstream
+
+import "github.com/vsc-blockchain/pricefeeder/types"
+
+// votingPeriodTracker tracks the voting periods signaled by the stream, so that a voting period
+// whose first block was missed, e.g. while the websocket was reconnecting, is still signaled.
+type votingPeriodTracker struct {
+	// byEvents makes the end of the voting periods known from the oracle events of the blocks
+	// instead of the vote period blocks, which are only used to catch up on missed blocks.
+	byEvents   bool
+	lastHeight uint64 // height of the last block seen
+	lastPeriod uint64 // height of the last voting period signaled, or of the first block seen
+}
+
+// next returns the voting period to signal after the block of the given height, if any.
+// periodEnded reports whether the oracle events of the block ended a voting period.
+func (t *votingPeriodTracker) next(height, votePeriodBlocks uint64, periodEnded bool) (types.VotingPeriod, bool) {
+	missedBlocks := t.lastHeight != 0 && height > t.lastHeight+1
+	t.lastHeight = height
+
+	nextHeight := height + 1
+	start := nextHeight - nextHeight%votePeriodBlocks
+	if t.byEvents {
+		switch {
+		case periodEnded:
+			start = nextHeight
+		case !missedBlocks:
+			return types.VotingPeriod{}, false
+		}
+	}
+
+	if t.lastPeriod == 0 && start != nextHeight {
+		// the feeder starts voting at the next voting period
+		t.lastPeriod = nextHeight
+		return types.VotingPeriod{}, false
+	}
+	if start <= t.lastPeriod {
+		return types.VotingPeriod{}, false
+	}
+	t.lastPeriod = start
+	return types.VotingPeriod{
+		Height:    start,
+		EndHeight: start + votePeriodBlocks,
+		CatchUp:   start != nextHeight,
+	}, true
+}
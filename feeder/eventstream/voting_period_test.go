@@ -0,0 +1,75 @@
+package eventstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func Test_votingPeriodTracker(t *testing.T) {
+	type block struct {
+		height      uint64
+		periodEnded bool
+	}
+	tests := []struct {
+		name     string
+		byEvents bool
+		blocks   []block
+		want     []types.VotingPeriod
+	}{
+		{
+			name:   "every period",
+			blocks: []block{{height: 8}, {height: 9}, {height: 10}, {height: 19}, {height: 20}},
+			want:   []types.VotingPeriod{{Height: 10, EndHeight: 20}, {Height: 20, EndHeight: 30}},
+		},
+		{
+			name:   "first block of the first period",
+			blocks: []block{{height: 9}},
+			want:   []types.VotingPeriod{{Height: 10, EndHeight: 20}},
+		},
+		{
+			name:   "no catch up at start",
+			blocks: []block{{height: 12}, {height: 13}},
+		},
+		{
+			name:   "catch up on a missed first block",
+			blocks: []block{{height: 8}, {height: 11}, {height: 12}, {height: 19}},
+			want:   []types.VotingPeriod{{Height: 10, EndHeight: 20, CatchUp: true}, {Height: 20, EndHeight: 30}},
+		},
+		{
+			name:   "catch up once on several missed periods",
+			blocks: []block{{height: 8}, {height: 35}, {height: 36}},
+			want:   []types.VotingPeriod{{Height: 30, EndHeight: 40, CatchUp: true}},
+		},
+		{
+			name:   "repeated block",
+			blocks: []block{{height: 9}, {height: 9}},
+			want:   []types.VotingPeriod{{Height: 10, EndHeight: 20}},
+		},
+		{
+			name:     "by events",
+			byEvents: true,
+			blocks:   []block{{height: 8}, {height: 9}, {height: 10, periodEnded: true}, {height: 11}},
+			want:     []types.VotingPeriod{{Height: 11, EndHeight: 21}},
+		},
+		{
+			name:     "by events catching up",
+			byEvents: true,
+			blocks:   []block{{height: 8}, {height: 9, periodEnded: true}, {height: 21}, {height: 22}},
+			want:     []types.VotingPeriod{{Height: 10, EndHeight: 20}, {Height: 20, EndHeight: 30, CatchUp: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &votingPeriodTracker{byEvents: tt.byEvents}
+			var got []types.VotingPeriod
+			for _, b := range tt.blocks {
+				if vp, ok := tracker.next(b.height, 10, b.periodEnded); ok {
+					got = append(got, vp)
+				}
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,13 +1,35 @@
 package eventstream
 
 import (
+	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
 )
 
+var (
+	// minReconnectDelay and maxReconnectDelay bound the exponential backoff between two dials.
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+	// pingInterval is the interval between two pings of the connection, and readTimeout the time
+	// without any message or pong after which the connection is considered dead.
+	pingInterval = 20 * time.Second
+	readTimeout  = 30 * time.Second
+	writeTimeout = 5 * time.Second
+)
+
+var websocketConnectedGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: metrics.PrometheusNamespace,
+	Name:      "websocket_connected",
+	Help:      "Whether the websocket of the event stream is connected (1) or not (0)",
+})
+
 type dianFn func() (*websocket.Conn, error)
 
 type ws struct {
@@ -15,18 +37,29 @@ type ws struct {
 	stopSignal       chan struct{} // external signal to stop the ws
 	done             chan struct{} // internal signal to wait for the ws to execute its shutdown operations
 	read             chan []byte
+	health           chan bool // latest connection state, not yet received
 	dial             dianFn
+	mu               sync.Mutex // guards connection
 	connection       *websocket.Conn
 	connectionClosed *atomic.Bool
 }
 
 func NewWebsocket(url string, onOpenMsg []byte, logger zerolog.Logger) *ws {
+	return newWebsocket(func() string { return url }, onOpenMsg, logger)
+}
+
+// newWebsocket returns a websocket dialing the url returned by endpoint on every (re)connection.
+func newWebsocket(endpoint func() string, onOpenMsg []byte, logger zerolog.Logger) *ws {
 	dialFunction := func() (*websocket.Conn, error) {
-		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		conn, _, err := websocket.DefaultDialer.Dial(endpoint(), nil)
 		if err != nil {
 			return nil, err
 		}
-		return conn, conn.WriteMessage(websocket.BinaryMessage, onOpenMsg)
+		if err := conn.WriteMessage(websocket.BinaryMessage, onOpenMsg); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return conn, nil
 	}
 
 	ws := &ws{
@@ -34,6 +67,7 @@ func NewWebsocket(url string, onOpenMsg []byte, logger zerolog.Logger) *ws {
 		stopSignal:       make(chan struct{}),
 		done:             make(chan struct{}),
 		read:             make(chan []byte),
+		health:           make(chan bool, 1),
 		dial:             dialFunction,
 		connection:       nil,
 		connectionClosed: new(atomic.Bool),
@@ -43,28 +77,90 @@ func NewWebsocket(url string, onOpenMsg []byte, logger zerolog.Logger) *ws {
 	return ws
 }
 
+// loop connects the websocket and reads its messages, reconnecting whenever the connection is lost.
 func (w *ws) loop() {
 	defer close(w.done)
 
-	if w.connection == nil {
-		w.connect()
+	for {
+		connection, ok := w.connect()
+		if !ok {
+			return
+		}
+		w.setConnected(true)
+		err := w.readMessages(connection)
+		w.setConnected(false)
+		if err == nil || w.connectionClosed.Load() {
+			// if the connection was closed, then we exit
+			return
+		}
+		w.logger.Err(err).Msg("disconnected from websocket, attempting to reconnect")
+
+		// a connection dropped right away, e.g. after a failed subscription, is not redialed in a busy loop
+		select {
+		case <-w.stopSignal:
+			return
+		case <-time.After(jitter(minReconnectDelay)):
+		}
 	}
+}
 
-	// read messages and also handles reconnection.
-	for {
-		_, bytes, err := w.connection.ReadMessage()
-		if err != nil {
+// connect dials the websocket until it succeeds, waiting between two attempts with a capped and
+// jittered exponential backoff. It returns false if the websocket was closed meanwhile.
+func (w *ws) connect() (*websocket.Conn, bool) {
+	w.logger.Debug().Msg("connecting")
+
+	delay := minReconnectDelay
+	for retries := 1; ; retries++ {
+		connection, err := w.dial()
+		if err == nil {
+			w.mu.Lock()
+			defer w.mu.Unlock()
 			if w.connectionClosed.Load() {
-				// if the connection was closed, then we exit
-				return
+				_ = connection.Close()
+				return nil, false
 			}
+			w.connection = connection
+			w.logger.Debug().Msg("connected to websocket")
+			return connection, true
+		}
 
-			// otherwise we attempt to reconnect
-			// we don't care if it fails, because if it does on ReadMessage we will receive an error
-			// and then attempt to reconnect again.
-			w.logger.Err(err).Msg("disconnected from websocket, attempting to reconnect")
-			w.connect()
-			continue
+		// if we failed to connect, we wait and try again
+		wait := jitter(delay)
+		w.logger.Err(err).Int("retries", retries).Dur("delay", wait).Msg("failed to connect to websocket, retrying")
+		select {
+		case <-w.stopSignal:
+			return nil, false
+		case <-time.After(wait):
+		}
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
+
+// readMessages forwards the messages of the connection until it fails, returning the error,
+// or until the websocket is closed, returning nil. The connection is pinged meanwhile, and
+// considered dead when neither a message nor a pong was received for readTimeout.
+func (w *ws) readMessages(connection *websocket.Conn) error {
+	defer func() {
+		w.mu.Lock()
+		w.connection = nil
+		w.mu.Unlock()
+		_ = connection.Close()
+	}()
+
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	stopKeepalive := make(chan struct{})
+	defer close(stopKeepalive)
+	go w.keepalive(connection, pingInterval, stopKeepalive)
+
+	for {
+		if err := connection.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return err
+		}
+		_, bytes, err := connection.ReadMessage()
+		if err != nil {
+			return err
 		}
 
 		// no error, forward the msg
@@ -73,49 +169,79 @@ func (w *ws) loop() {
 			w.logger.Debug().Str("payload", string(bytes)).Msg("message received")
 		case <-w.stopSignal:
 			w.logger.Warn().Str("payload", string(bytes)).Msg("message dropped due to shutdown")
-			return
+			return nil
 		}
 	}
 }
 
-// attemps to dial the websocket using binary exponential backoff
-func (w *ws) connect() {
-	w.logger.Debug().Msg("connecting")
+// keepalive pings the connection every interval until stop is closed.
+func (w *ws) keepalive(connection *websocket.Conn, interval time.Duration, stop <-chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
 
-	retries := 0
-	delay := 1 * time.Second
 	for {
-		connection, err := w.dial()
-		if err == nil {
-			w.connection = connection
-			w.logger.Debug().Msg("connected to websocket")
+		select {
+		case <-stop:
 			return
+		case <-tick.C:
+			// a failed ping is caught by the read deadline
+			if err := connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				w.logger.Debug().Err(err).Msg("ping failed")
+			}
 		}
-		// if we failed to connect, we wait and try again
-		w.logger.Err(err).Msg("failed to connect to websocket")
-		retries++
-		if retries > 10 {
-			// if we failed to connect more than 10 times, we exit
-			w.logger.Fatal().Msg("failed to connect to websocket")
-		}
+	}
+}
 
-		w.logger.Debug().Int("retries", retries).Msg("failed to connect to websocket, retrying")
-		time.Sleep(delay)
-		delay *= 2
+// setConnected replaces the connection state not yet received, if any, by the new one,
+// so that the receiver never blocks the websocket and always gets the latest state.
+func (w *ws) setConnected(connected bool) {
+	if connected {
+		websocketConnectedGauge.Set(1)
+	} else {
+		websocketConnectedGauge.Set(0)
+	}
+	select {
+	case <-w.health:
+	default:
 	}
+	w.health <- connected
+}
+
+// jitter returns a random delay between half the given delay and the delay.
+func jitter(delay time.Duration) time.Duration {
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
 }
 
 func (w *ws) message() <-chan []byte {
 	return w.read
 }
 
+// connected signals the changes of the connection state: true once connected, false once disconnected.
+func (w *ws) connected() <-chan bool {
+	return w.health
+}
+
+// reconnect closes the current connection for the loop to dial the endpoint again.
+func (w *ws) reconnect() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.connectionClosed.Load() || w.connection == nil {
+		return
+	}
+	if err := w.connection.Close(); err != nil {
+		w.logger.Err(err).Msg("close error")
+	}
+}
+
 func (w *ws) close() {
 	close(w.stopSignal)
+	w.mu.Lock()
 	w.connectionClosed.Store(true)
 	if w.connection != nil {
 		if err := w.connection.Close(); err != nil {
 			w.logger.Err(err).Msg("close error")
 		}
 	}
+	w.mu.Unlock()
 	<-w.done
 }
@@ -1,10 +1,17 @@
 package eventstream
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 )
@@ -34,3 +41,190 @@ func TestWebsocketExplicitClose(t *testing.T) {
 		ws.close()
 	})
 }
+
+// newTestServer returns the url of a local websocket server handling each connection with handle.
+func newTestServer(t *testing.T, handle func(conn *websocket.Conn)) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// setTestTimeouts shortens the backoff and keepalive durations for the test.
+func setTestTimeouts(t *testing.T) {
+	minDelay, maxDelay, ping, read := minReconnectDelay, maxReconnectDelay, pingInterval, readTimeout
+	t.Cleanup(func() {
+		minReconnectDelay, maxReconnectDelay, pingInterval, readTimeout = minDelay, maxDelay, ping, read
+	})
+	minReconnectDelay, maxReconnectDelay = time.Millisecond, 5*time.Millisecond
+	pingInterval, readTimeout = 20*time.Millisecond, 100*time.Millisecond
+}
+
+func requireMessage(t *testing.T, ws *ws, want string) {
+	t.Helper()
+	select {
+	case msg := <-ws.message():
+		require.Equal(t, want, string(msg))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func requireConnected(t *testing.T, ws *ws, want bool) {
+	t.Helper()
+	select {
+	case connected := <-ws.connected():
+		require.Equal(t, want, connected)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func readUntilClosed(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestWebsocketReconnect(t *testing.T) {
+	setTestTimeouts(t)
+
+	// each server sends its name to the clients connecting to it
+	newServer := func(name string) string {
+		return newTestServer(t, func(conn *websocket.Conn) {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(name))
+			readUntilClosed(conn)
+		})
+	}
+	endpoint := new(atomic.Value)
+	endpoint.Store(newServer("a"))
+	b := newServer("b")
+
+	ws := newWebsocket(func() string { return endpoint.Load().(string) }, []byte("subscribe"), zerolog.New(os.Stderr))
+	defer ws.close()
+	for _, want := range []string{"a", "b"} {
+		requireMessage(t, ws, want)
+		endpoint.Store(b)
+		ws.reconnect()
+	}
+}
+
+func TestWebsocketDroppedConnection(t *testing.T) {
+	setTestTimeouts(t)
+
+	// the server sends the number of the connection, then drops it
+	connections := new(atomic.Int32)
+	url := newTestServer(t, func(conn *websocket.Conn) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprint(connections.Add(1))))
+	})
+
+	ws := NewWebsocket(url, []byte("subscribe"), zerolog.New(os.Stderr))
+	defer ws.close()
+	for _, want := range []string{"1", "2", "3"} {
+		requireMessage(t, ws, want)
+	}
+}
+
+func TestWebsocketConnected(t *testing.T) {
+	setTestTimeouts(t)
+
+	// the server drops the connection when asked to, and is unreachable afterwards
+	drop := make(chan struct{})
+	url := newTestServer(t, func(conn *websocket.Conn) {
+		<-drop
+	})
+	dials := new(atomic.Int32)
+	endpoint := func() string {
+		if dials.Add(1) == 1 {
+			return url
+		}
+		return "ws://127.0.0.1:1/websocket"
+	}
+
+	ws := newWebsocket(endpoint, []byte("subscribe"), zerolog.New(io.Discard))
+	defer ws.close()
+	requireConnected(t, ws, true)
+	close(drop)
+	requireConnected(t, ws, false)
+}
+
+func TestWebsocketHalfOpenConnection(t *testing.T) {
+	setTestTimeouts(t)
+
+	// the server neither reads nor answers the pings of the connection until the test ends
+	stop := make(chan struct{})
+	connections := new(atomic.Int32)
+	url := newTestServer(t, func(conn *websocket.Conn) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprint(connections.Add(1))))
+		<-stop
+	})
+	t.Cleanup(func() { close(stop) })
+
+	ws := NewWebsocket(url, []byte("subscribe"), zerolog.New(os.Stderr))
+	defer ws.close()
+	requireMessage(t, ws, "1")
+	// the read deadline detects the dead connection and the websocket reconnects
+	requireMessage(t, ws, "2")
+}
+
+func TestWebsocketKeepalive(t *testing.T) {
+	setTestTimeouts(t)
+
+	// the server answers the pings but sends no message after the first one
+	connections := new(atomic.Int32)
+	url := newTestServer(t, func(conn *websocket.Conn) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprint(connections.Add(1))))
+		readUntilClosed(conn)
+	})
+
+	ws := NewWebsocket(url, []byte("subscribe"), zerolog.New(os.Stderr))
+	defer ws.close()
+	requireMessage(t, ws, "1")
+	// the pongs keep the connection alive past the read timeout
+	time.Sleep(5 * readTimeout)
+	require.Equal(t, int32(1), connections.Load())
+}
+
+func TestWebsocketUnreachableEndpoint(t *testing.T) {
+	setTestTimeouts(t)
+
+	url := newTestServer(t, func(conn *websocket.Conn) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("connected"))
+		readUntilClosed(conn)
+	})
+	// the endpoint is unreachable for more dials than the websocket used to give up after
+	dials := new(atomic.Int32)
+	endpoint := func() string {
+		if dials.Add(1) <= 20 {
+			return "ws://127.0.0.1:1/websocket"
+		}
+		return url
+	}
+
+	ws := newWebsocket(endpoint, []byte("subscribe"), zerolog.New(io.Discard))
+	defer ws.close()
+	requireMessage(t, ws, "connected")
+}
+
+func TestWebsocketCloseWhileConnecting(t *testing.T) {
+	setTestTimeouts(t)
+
+	ws := NewWebsocket("ws://127.0.0.1:1/websocket", []byte("subscribe"), zerolog.New(io.Discard))
+	require.NotPanics(t, ws.close)
+}
+
+func Test_jitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		delay := jitter(time.Second)
+		require.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		require.LessOrEqual(t, delay, time.Second)
+	}
+}
@@ -0,0 +1,95 @@
+package failover
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var _ grpc.ClientConnInterface = (*Conn)(nil)
+
+// Conn is a grpc client connection to a Pool of grpc endpoints, sending each call
+// to the active endpoint.
+type Conn struct {
+	pool  *Pool
+	conns map[string]*grpc.ClientConn
+}
+
+// DialGRPC connects to every grpc endpoint and returns a Conn to them, whose Pool
+// is named after its use in the metrics.
+func DialGRPC(name string, endpoints []string, enableTLS bool, logger zerolog.Logger) (*Conn, error) {
+	transportDialOpt := grpc.WithInsecure()
+
+	if enableTLS {
+		transportDialOpt = grpc.WithTransportCredentials(
+			credentials.NewTLS(
+				&tls.Config{
+					InsecureSkipVerify: false,
+				},
+			),
+		)
+	}
+
+	conns := make(map[string]*grpc.ClientConn, len(endpoints))
+	for _, endpoint := range endpoints {
+		conn, err := grpc.Dial(endpoint, transportDialOpt)
+		if err != nil {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+			return nil, fmt.Errorf("failed to dial %s: %w", endpoint, err)
+		}
+		conns[endpoint] = conn
+	}
+
+	return &Conn{
+		pool:  NewPool(name, endpoints, checkGRPC(conns), logger),
+		conns: conns,
+	}, nil
+}
+
+// Invoke performs a unary RPC on the active endpoint.
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.conns[c.pool.Active()].Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream begins a streaming RPC on the active endpoint.
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.conns[c.pool.Active()].NewStream(ctx, desc, method, opts...)
+}
+
+// Close stops the health checks and closes the connections to the endpoints.
+func (c *Conn) Close() error {
+	c.pool.Close()
+	var errs []error
+	for _, conn := range c.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// checkGRPC returns the CheckFunc querying the node service of the endpoints.
+func checkGRPC(conns map[string]*grpc.ClientConn) CheckFunc {
+	return func(ctx context.Context, endpoint string) (Health, error) {
+		node := cmtservice.NewServiceClient(conns[endpoint])
+
+		syncing, err := node.GetSyncing(ctx, &cmtservice.GetSyncingRequest{})
+		if err != nil {
+			return Health{}, err
+		}
+		block, err := node.GetLatestBlock(ctx, &cmtservice.GetLatestBlockRequest{})
+		if err != nil {
+			return Health{}, err
+		}
+		return Health{
+			Height:     block.GetSdkBlock().GetHeader().Height,
+			CatchingUp: syncing.Syncing,
+		}, nil
+	}
+}
@@ -0,0 +1,231 @@
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
+)
+
+var (
+	// CheckInterval is the interval between two health checks of the endpoints of a Pool.
+	CheckInterval = 10 * time.Second
+	// CheckTimeout bounds the health check of an endpoint.
+	CheckTimeout = 3 * time.Second
+	// MaxLag is the number of blocks the active endpoint can lag behind the highest
+	// healthy one before failing over, so that endpoints a block apart do not flap.
+	MaxLag int64 = 2
+)
+
+var (
+	activeEndpointGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Name:      "active_endpoint",
+		Help:      "Whether the endpoint is the active one of its pool (1) or not (0)",
+	}, []string{"pool", "endpoint"})
+
+	endpointHeightGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Name:      "endpoint_height",
+		Help:      "The latest block height of the endpoint, as of its last successful health check",
+	}, []string{"pool", "endpoint"})
+
+	failoversCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Name:      "failovers_total",
+		Help:      "The total number of failovers from an endpoint to another, by pool",
+	}, []string{"pool"})
+)
+
+// Health is the status of an endpoint as reported by its node.
+type Health struct {
+	// Height is the latest block height of the node.
+	Height int64
+	// CatchingUp reports whether the node is still syncing blocks.
+	CatchingUp bool
+}
+
+// CheckFunc returns the Health of the endpoint, or an error if it could not be obtained.
+type CheckFunc func(ctx context.Context, endpoint string) (Health, error)
+
+// Pool checks the health of a list of endpoints and keeps the healthiest one active.
+// An endpoint is healthy when its health check succeeds and its node is not catching up,
+// the healthiest one being the healthy endpoint with the highest block height.
+// A Pool of a single endpoint has nothing to fail over to and does not check it.
+type Pool struct {
+	name      string
+	endpoints []string
+	check     CheckFunc
+	logger    zerolog.Logger
+
+	mu         sync.Mutex
+	active     int
+	onFailover []func(endpoint string)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPool returns a Pool of the given endpoints, named after its use in the metrics.
+// The first endpoint is active until the first health check, which runs before NewPool returns.
+func NewPool(name string, endpoints []string, check CheckFunc, logger zerolog.Logger) *Pool {
+	if len(endpoints) == 0 {
+		panic("failover: no endpoints")
+	}
+
+	p := &Pool{
+		name:      name,
+		endpoints: endpoints,
+		check:     check,
+		logger:    logger.With().Str("component", "failover").Str("pool", name).Logger(),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for i, endpoint := range endpoints {
+		activeEndpointGauge.WithLabelValues(name, endpoint).Set(boolToFloat(i == 0))
+	}
+
+	if len(endpoints) == 1 {
+		close(p.done)
+		return p
+	}
+	p.checkEndpoints()
+	go p.loop()
+	return p
+}
+
+// Endpoints returns the endpoints of the Pool.
+func (p *Pool) Endpoints() []string {
+	return p.endpoints
+}
+
+// Active returns the active endpoint.
+func (p *Pool) Active() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.endpoints[p.active]
+}
+
+// OnFailover registers fn to be called with the new active endpoint after each failover.
+func (p *Pool) OnFailover(fn func(endpoint string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onFailover = append(p.onFailover, fn)
+}
+
+// Close stops the health checks.
+func (p *Pool) Close() {
+	select {
+	case <-p.done:
+		return
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	<-p.done
+}
+
+func (p *Pool) loop() {
+	defer close(p.done)
+
+	tick := time.NewTicker(CheckInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-tick.C:
+			p.checkEndpoints()
+		}
+	}
+}
+
+// checkEndpoints checks every endpoint concurrently and fails over to the healthiest one if needed.
+func (p *Pool) checkEndpoints() {
+	results := make([]checkResult, len(p.endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range p.endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), CheckTimeout)
+			defer cancel()
+			results[i].health, results[i].err = p.check(ctx, endpoint)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		switch {
+		case result.err != nil:
+			p.logger.Warn().Err(result.err).Str("endpoint", p.endpoints[i]).Msg("endpoint health check failed")
+		case result.health.CatchingUp:
+			p.logger.Warn().Str("endpoint", p.endpoints[i]).Int64("height", result.health.Height).Msg("endpoint is catching up")
+		}
+		if result.err == nil {
+			endpointHeightGauge.WithLabelValues(p.name, p.endpoints[i]).Set(float64(result.health.Height))
+		}
+	}
+
+	p.mu.Lock()
+	previous := p.active
+	p.active = selectEndpoint(results, previous)
+	active, onFailover := p.active, p.onFailover
+	p.mu.Unlock()
+
+	if active == previous {
+		return
+	}
+	activeEndpointGauge.WithLabelValues(p.name, p.endpoints[previous]).Set(0)
+	activeEndpointGauge.WithLabelValues(p.name, p.endpoints[active]).Set(1)
+	failoversCounter.WithLabelValues(p.name).Inc()
+	p.logger.Warn().
+		Str("unhealthy", p.endpoints[previous]).
+		Str("endpoint", p.endpoints[active]).
+		Int64("height", results[active].health.Height).
+		Msg("failing over to the healthiest endpoint")
+	for _, fn := range onFailover {
+		fn(p.endpoints[active])
+	}
+}
+
+type checkResult struct {
+	health Health
+	err    error
+}
+
+func (r checkResult) healthy() bool {
+	return r.err == nil && !r.health.CatchingUp
+}
+
+// selectEndpoint returns the index of the endpoint to make active. The active endpoint is kept
+// while it is healthy and at most MaxLag blocks behind the highest healthy endpoint, or when
+// no endpoint is healthy.
+func selectEndpoint(results []checkResult, active int) int {
+	best := -1
+	for i, result := range results {
+		if result.healthy() && (best == -1 || result.health.Height > results[best].health.Height) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return active
+	}
+	if current := results[active]; current.healthy() && current.health.Height+MaxLag >= results[best].health.Height {
+		return active
+	}
+	return best
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
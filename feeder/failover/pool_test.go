@@ -0,0 +1,88 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_selectEndpoint(t *testing.T) {
+	healthy := func(height int64) checkResult { return checkResult{health: Health{Height: height}} }
+	catchingUp := checkResult{health: Health{Height: 100, CatchingUp: true}}
+	failed := checkResult{err: errors.New("unreachable")}
+
+	tests := []struct {
+		name    string
+		results []checkResult
+		active  int
+		want    int
+	}{
+		{"keeps the highest", []checkResult{healthy(10), healthy(9)}, 0, 0},
+		{"keeps within the max lag", []checkResult{healthy(10), healthy(10 + MaxLag)}, 0, 0},
+		{"fails over when lagging", []checkResult{healthy(10), healthy(11 + MaxLag)}, 0, 1},
+		{"fails over when unreachable", []checkResult{failed, healthy(1), healthy(2)}, 0, 2},
+		{"fails over when catching up", []checkResult{catchingUp, healthy(10)}, 0, 1},
+		{"ignores catching up endpoints", []checkResult{healthy(10), catchingUp}, 0, 0},
+		{"keeps the active one when none is healthy", []checkResult{failed, catchingUp}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, selectEndpoint(tt.results, tt.active))
+		})
+	}
+}
+
+func TestPool(t *testing.T) {
+	defer func(interval time.Duration) { CheckInterval = interval }(CheckInterval)
+	CheckInterval = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	heights := map[string]int64{"a": 10, "b": 10}
+	check := func(_ context.Context, endpoint string) (Health, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return Health{Height: heights[endpoint]}, nil
+	}
+
+	pool := NewPool("test", []string{"a", "b"}, check, zerolog.New(io.Discard))
+	defer pool.Close()
+	require.Equal(t, "a", pool.Active())
+	require.Equal(t, 1.0, testutil.ToFloat64(activeEndpointGauge.WithLabelValues("test", "a")))
+
+	failedOver := make(chan string, 1)
+	pool.OnFailover(func(endpoint string) { failedOver <- endpoint })
+
+	mu.Lock()
+	heights["b"] = 20
+	mu.Unlock()
+
+	select {
+	case endpoint := <-failedOver:
+		require.Equal(t, "b", endpoint)
+	case <-time.After(time.Second):
+		t.Fatal("no failover")
+	}
+	require.Equal(t, "b", pool.Active())
+	require.Equal(t, 0.0, testutil.ToFloat64(activeEndpointGauge.WithLabelValues("test", "a")))
+	require.Equal(t, 1.0, testutil.ToFloat64(activeEndpointGauge.WithLabelValues("test", "b")))
+	require.Equal(t, 20.0, testutil.ToFloat64(endpointHeightGauge.WithLabelValues("test", "b")))
+	require.Equal(t, 1.0, testutil.ToFloat64(failoversCounter.WithLabelValues("test")))
+}
+
+func TestPool_SingleEndpoint(t *testing.T) {
+	check := func(context.Context, string) (Health, error) {
+		t.Fatal("unexpected health check")
+		return Health{}, nil
+	}
+
+	pool := NewPool("single", []string{"a"}, check, zerolog.New(io.Discard))
+	require.Equal(t, "a", pool.Active())
+	require.NotPanics(t, pool.Close)
+}
@@ -0,0 +1,70 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CheckWebsocket is the CheckFunc of CometBFT websocket endpoints, which queries the
+// /status route of the RPC server serving the websocket.
+func CheckWebsocket(ctx context.Context, endpoint string) (Health, error) {
+	statusURL, err := statusURL(endpoint)
+	if err != nil {
+		return Health{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+	if err != nil {
+		return Health{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Health{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Health{}, fmt.Errorf("status request failed: %s", resp.Status)
+	}
+
+	var status struct {
+		Result struct {
+			SyncInfo struct {
+				LatestBlockHeight string `json:"latest_block_height"`
+				CatchingUp        bool   `json:"catching_up"`
+			} `json:"sync_info"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return Health{}, fmt.Errorf("invalid status response: %w", err)
+	}
+	height, err := strconv.ParseInt(status.Result.SyncInfo.LatestBlockHeight, 10, 64)
+	if err != nil {
+		return Health{}, fmt.Errorf("invalid latest block height: %w", err)
+	}
+	return Health{Height: height, CatchingUp: status.Result.SyncInfo.CatchingUp}, nil
+}
+
+// statusURL returns the URL of the /status route of the RPC server of a websocket endpoint,
+// e.g. http://localhost:26657/status for ws://localhost:26657/websocket.
+func statusURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return "", fmt.Errorf("invalid websocket endpoint scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/websocket") + "/status"
+	u.RawQuery = ""
+	return u.String(), nil
+}
@@ -0,0 +1,36 @@
+package failover
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_statusURL(t *testing.T) {
+	u, err := statusURL("ws://localhost:26657/websocket")
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:26657/status", u)
+
+	u, err = statusURL("wss://rpc.example.com/vsc/websocket")
+	require.NoError(t, err)
+	require.Equal(t, "https://rpc.example.com/vsc/status", u)
+
+	_, err = statusURL("http://localhost:26657")
+	require.Error(t, err)
+}
+
+func TestCheckWebsocket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/status", r.URL.Path)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"result":{"sync_info":{"latest_block_height":"1234","catching_up":true}}}`))
+	}))
+	defer server.Close()
+
+	health, err := CheckWebsocket(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http")+"/websocket")
+	require.NoError(t, err)
+	require.Equal(t, Health{Height: 1234, CatchingUp: true}, health)
+}
@@ -1,7 +1,10 @@
 package feeder
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -16,10 +19,13 @@ var (
 type Feeder struct {
 	logger zerolog.Logger
 
-	stop chan struct{}
-	done chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once // guards closing stop, the feeder being closed or shut down any number of times
+	done     chan struct{}
 
 	params types.Params
+	// connected reports whether the event stream is connected to the chain.
+	connected atomic.Bool
 
 	eventStream   types.EventStream
 	pricePoster   types.PricePoster
@@ -70,7 +76,16 @@ func (f *Feeder) loop() {
 		case params := <-f.eventStream.ParamsUpdate():
 			f.logger.Info().Interface("changes", params).Msg("params changed")
 			f.handleParamsUpdate(params)
+		case connected := <-f.eventStream.Connected():
+			f.handleConnected(connected)
 		case vp := <-f.eventStream.VotingPeriodStarted():
+			// a voting period started while stopping is not handled
+			select {
+			case <-f.stop:
+				f.logger.Debug().Msg("stop signal received")
+				return
+			default:
+			}
 			f.logger.Info().Interface("voting-period", vp).Msg("new voting period")
 			f.handleVotingPeriod(vp)
 		}
@@ -89,14 +104,28 @@ func (f *Feeder) handleParamsUpdate(params types.Params) {
 	f.params = params
 }
 
+func (f *Feeder) handleConnected(connected bool) {
+	f.connected.Store(connected)
+	if !connected {
+		f.logger.Warn().Msg("event stream disconnected, voting periods are missed until it reconnects")
+		return
+	}
+	f.logger.Info().Msg("event stream connected")
+}
+
+// Healthy reports whether the feeder is able to vote, that is whether its event stream is connected.
+func (f *Feeder) Healthy() bool {
+	return f.connected.Load()
+}
+
 func (f *Feeder) handleVotingPeriod(vp types.VotingPeriod) {
 	// gather prices
 	prices := make([]types.Price, len(f.params.Pairs))
 	for i, p := range f.params.Pairs {
 		price := f.priceProvider.GetPrice(p)
 		if !price.Valid {
-			f.logger.Err(fmt.Errorf("no valid price")).Str("asset", p.String()).Str("source", price.SourceName)
-			price.Price = 0
+			// the price poster abstains from voting invalid prices
+			f.logger.Err(fmt.Errorf("no valid price")).Str("asset", p.String()).Str("source", price.SourceName).Msg("abstaining from voting")
 		}
 		prices[i] = price
 	}
@@ -106,6 +135,19 @@ func (f *Feeder) handleVotingPeriod(vp types.VotingPeriod) {
 }
 
 func (f *Feeder) Close() {
-	close(f.stop)
-	<-f.done
+	_ = f.Shutdown(context.Background())
+}
+
+// Shutdown stops the feeder and closes its components once the voting period being
+// handled, if any, is done, so that an in-flight vote is not interrupted. It returns
+// the context error if the context is done first, the feeder still stopping in the background.
+// It can be called again, e.g. by Close, to wait for the feeder to stop.
+func (f *Feeder) Shutdown(ctx context.Context) error {
+	f.stopOnce.Do(func() { close(f.stop) })
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -1,10 +1,12 @@
 package feeder
 
 import (
+	"context"
 	"io"
 	"testing"
 	"time"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/golang/mock/gomock"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -42,35 +44,80 @@ func TestParamsUpdate(t *testing.T) {
 	require.Equal(t, tf.feeder.params, p)
 }
 
+func TestConnected(t *testing.T) {
+	tf := initFeeder(t)
+	defer tf.feeder.Close()
+	require.False(t, tf.feeder.Healthy())
+
+	tf.connectedChannel <- true
+	require.Eventually(t, tf.feeder.Healthy, time.Second, time.Millisecond)
+
+	tf.connectedChannel <- false
+	require.Eventually(t, func() bool { return !tf.feeder.Healthy() }, time.Second, time.Millisecond)
+}
+
 func TestVotingPeriod(t *testing.T) {
 	tf := initFeeder(t)
 	defer tf.feeder.Close()
 
 	validPrice := types.Price{
 		Pair:       asset.Registry.Pair(denoms.BTC, denoms.NUSD),
-		Price:      100_000.8,
+		Price:      sdkmath.LegacyMustNewDecFromStr("100000.8"),
 		SourceName: "mock-source",
 		Valid:      true,
 	}
 
 	invalidPrice := types.Price{
 		Pair:       asset.Registry.Pair(denoms.ETH, denoms.NUSD),
-		Price:      7000.11,
+		Price:      sdkmath.LegacyMustNewDecFromStr("7000.11"),
 		SourceName: "mock-source",
 		Valid:      false,
 	}
 
-	abstainPrice := invalidPrice
-	abstainPrice.Price = 0.0
-
 	tf.mockPriceProvider.EXPECT().GetPrice(asset.Registry.Pair(denoms.BTC, denoms.NUSD)).Return(validPrice)
 	tf.mockPriceProvider.EXPECT().GetPrice(asset.Registry.Pair(denoms.ETH, denoms.NUSD)).Return(invalidPrice)
-	tf.mockPricePoster.EXPECT().SendPrices(gomock.Any(), []types.Price{validPrice, abstainPrice})
+	tf.mockPricePoster.EXPECT().SendPrices(gomock.Any(), []types.Price{validPrice, invalidPrice})
 	// trigger voting period.
 	tf.newVotingPeriod <- types.VotingPeriod{Height: 100}
 	time.Sleep(10 * time.Millisecond)
 }
 
+func TestShutdown(t *testing.T) {
+	newHarness := func(t *testing.T, sendDuration time.Duration) testFeederHarness {
+		tf := initFeeder(t)
+		tf.mockPriceProvider.EXPECT().GetPrice(gomock.Any()).AnyTimes().Return(types.Price{})
+		tf.mockPricePoster.EXPECT().SendPrices(gomock.Any(), gomock.Any()).Do(func(types.VotingPeriod, []types.Price) {
+			time.Sleep(sendDuration)
+		})
+		tf.newVotingPeriod <- types.VotingPeriod{Height: 100}
+		time.Sleep(10 * time.Millisecond)
+		return tf
+	}
+
+	t.Run("in-flight vote completes", func(t *testing.T) {
+		tf := newHarness(t, 100*time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, tf.feeder.Shutdown(ctx))
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		tf := newHarness(t, 200*time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, tf.feeder.Shutdown(ctx), context.DeadlineExceeded)
+		// the feeder still stops once the vote is done
+		<-tf.feeder.done
+	})
+
+	t.Run("shutdown then close", func(t *testing.T) {
+		tf := newHarness(t, 0)
+		require.NoError(t, tf.feeder.Shutdown(context.Background()))
+		// the components are closed once, as expected by the mocks
+		tf.feeder.Close()
+	})
+}
+
 type testFeederHarness struct {
 	feeder            *Feeder
 	mockPriceProvider *mocks.MockPriceProvider
@@ -78,6 +125,7 @@ type testFeederHarness struct {
 	mockPricePoster   *mocks.MockPricePoster
 	newVotingPeriod   chan types.VotingPeriod
 	paramsChannel     chan types.Params
+	connectedChannel  chan bool
 }
 
 func initFeeder(t *testing.T) testFeederHarness {
@@ -93,6 +141,9 @@ func initFeeder(t *testing.T) testFeederHarness {
 	votingPeriodChannel := make(chan types.VotingPeriod, 1)
 	eventStream.EXPECT().VotingPeriodStarted().AnyTimes().Return(votingPeriodChannel)
 
+	connectedChannel := make(chan bool, 1)
+	eventStream.EXPECT().Connected().AnyTimes().Return(connectedChannel)
+
 	feeder := &Feeder{
 		stop:          make(chan struct{}),
 		done:          make(chan struct{}),
@@ -115,5 +166,6 @@ func initFeeder(t *testing.T) testFeederHarness {
 		mockPricePoster:   pricePoster,
 		newVotingPeriod:   votingPeriodChannel,
 		paramsChannel:     paramsChannel,
+		connectedChannel:  connectedChannel,
 	}
 }
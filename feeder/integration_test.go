@@ -61,16 +61,16 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	log := zerolog.New(io.MultiWriter(os.Stderr, s.logs)).Level(zerolog.InfoLevel)
 
 	enableTLS := false
-	eventStream := eventstream.Dial(u.String(), grpcEndpoint, enableTLS, log)
+	eventStream := eventstream.Dial([]string{u.String()}, []string{grpcEndpoint}, enableTLS, "", time.Minute, log)
 	priceProvider := priceprovider.NewPriceProvider(sources.Bitfinex, map[asset.Pair]types.Symbol{
 		asset.Registry.Pair(denoms.BTC, denoms.NUSD): "tBTCUSD",
 		asset.Registry.Pair(denoms.ETH, denoms.NUSD): "tETHUSD",
 	}, json.RawMessage{}, log)
 	pricePoster := priceposter.Dial(
-		grpcEndpoint,
+		[]string{grpcEndpoint},
 		s.cfg.ChainID,
 		enableTLS,
-		val.ClientCtx.Keyring, val.ValAddress, val.Address, log)
+		val.ClientCtx.Keyring, val.ValAddress, val.Address, priceposter.DeviationGuard{}, priceposter.DefaultFeeConfig, "", log)
 	s.feeder = feeder.NewFeeder(eventStream, priceProvider, pricePoster, log)
 	s.feeder.Run()
 }
@@ -2,10 +2,14 @@ package priceposter
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
 	"time"
 
+	abcitypes "github.com/cometbft/cometbft/abci/types"
 	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
@@ -19,16 +23,21 @@ import (
 	"github.com/vsc-blockchain/core/crypto/ethsecp256k1"
 	coretypes "github.com/vsc-blockchain/core/types"
 	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/feeder/failover"
 	"github.com/vsc-blockchain/pricefeeder/metrics"
 	"github.com/vsc-blockchain/pricefeeder/types"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 )
 
 var _ types.PricePoster = (*Client)(nil)
 
+// VoteTimeout bounds the time spent voting in a voting period, including
+// the wait for the inclusion of the vote and its retries.
+var VoteTimeout = 1 * time.Minute
+
 type Oracle interface {
 	AggregatePrevote(context.Context, *oracletypes.QueryAggregatePrevoteRequest, ...grpc.CallOption) (*oracletypes.QueryAggregatePrevoteResponse, error)
+	ExchangeRate(context.Context, *oracletypes.QueryExchangeRateRequest, ...grpc.CallOption) (*oracletypes.QueryExchangeRateResponse, error)
 }
 
 type Auth interface {
@@ -37,40 +46,41 @@ type Auth interface {
 
 type TxService interface {
 	BroadcastTx(context.Context, *txservice.BroadcastTxRequest, ...grpc.CallOption) (*txservice.BroadcastTxResponse, error)
+	Simulate(context.Context, *txservice.SimulateRequest, ...grpc.CallOption) (*txservice.SimulateResponse, error)
+	GetTx(context.Context, *txservice.GetTxRequest, ...grpc.CallOption) (*txservice.GetTxResponse, error)
+}
+
+type Node interface {
+	GetLatestBlock(context.Context, *cmtservice.GetLatestBlockRequest, ...grpc.CallOption) (*cmtservice.GetLatestBlockResponse, error)
 }
 
 type deps struct {
 	oracleClient Oracle
 	authClient   Auth
 	txClient     TxService
+	nodeClient   Node
 	keyBase      keyring.Keyring
 	txConfig     client.TxConfig
 	ir           codectypes.InterfaceRegistry
 	chainID      string
+	account      *account
+	fees         *fees
 }
 
+// Dial connects to the grpc endpoints, broadcasting the txs to the healthiest one.
 func Dial(
-	grpcEndpoint string,
+	grpcEndpoints []string,
 	chainID string,
 	enableTLS bool,
 	keyBase keyring.Keyring,
 	validator sdk.ValAddress,
 	feeder sdk.AccAddress,
+	deviationGuard DeviationGuard,
+	feeConfig FeeConfig,
+	stateFile string,
 	logger zerolog.Logger,
 ) *Client {
-	transportDialOpt := grpc.WithInsecure()
-
-	if enableTLS {
-		transportDialOpt = grpc.WithTransportCredentials(
-			credentials.NewTLS(
-				&tls.Config{
-					InsecureSkipVerify: false,
-				},
-			),
-		)
-	}
-
-	conn, err := grpc.Dial(grpcEndpoint, transportDialOpt)
+	conn, err := failover.DialGRPC("priceposter_grpc", grpcEndpoints, enableTLS, logger)
 	if err != nil {
 		panic(err)
 	}
@@ -84,17 +94,26 @@ func Dial(
 		oracleClient: oracletypes.NewQueryClient(conn),
 		authClient:   authtypes.NewQueryClient(conn),
 		txClient:     txservice.NewServiceClient(conn),
+		nodeClient:   cmtservice.NewServiceClient(conn),
 		keyBase:      keyBase,
 		txConfig:     encoding.TxConfig,
 		ir:           encoding.InterfaceRegistry,
 		chainID:      chainID,
+		account:      new(account),
+		fees:         newFees(feeConfig),
 	}
 
+	previousPrevote, previousPeriod := recoverPrevote(stateFile, deps.oracleClient, validator, feeder, logger)
 	return &Client{
-		logger:    logger,
-		validator: validator,
-		feeder:    feeder,
-		deps:      deps,
+		logger:          logger,
+		validator:       validator,
+		feeder:          feeder,
+		deviationGuard:  deviationGuard,
+		stateFile:       stateFile,
+		previousPrevote: previousPrevote,
+		previousPeriod:  previousPeriod,
+		deps:            deps,
+		conn:            conn,
 	}
 }
 
@@ -104,8 +123,14 @@ type Client struct {
 	validator sdk.ValAddress
 	feeder    sdk.AccAddress
 
+	deviationGuard DeviationGuard
+
+	// stateFile persists the previous prevote, if set.
+	stateFile       string
 	previousPrevote *prevote
+	previousPeriod  uint64 // height of the voting period the previous prevote was sent in
 	deps            deps
+	conn            io.Closer
 }
 
 func (c *Client) Whoami() sdk.ValAddress {
@@ -118,24 +143,84 @@ var pricePosterCounter = promauto.NewCounterVec(prometheus.CounterOpts{
 	Help:      "The total number of price update txs sent to the chain, by success status",
 }, []string{"success"})
 
+// SendPrices votes the prices of the previous voting period and prevotes the given ones, then waits
+// for the inclusion of the tx, which is resent while the voting period is open if it failed.
 func (c *Client) SendPrices(vp types.VotingPeriod, prices []types.Price) {
 	logger := c.logger.With().Uint64("voting-period-height", vp.Height).Logger()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), VoteTimeout)
 	defer cancel()
 
-	newPrevote := newPrevote(prices, c.validator, c.feeder)
-	resp, err := vote(ctx, newPrevote, c.previousPrevote, c.validator, c.feeder, c.deps, logger)
-	if err != nil {
-		logger.Err(err).Msg("prevote failed")
-		pricePosterCounter.WithLabelValues("false").Inc()
-		return
+	prices = checkDeviations(ctx, c.deps.oracleClient, c.deviationGuard, prices, logger)
+
+	// the txs can only be included during the voting period
+	var timeoutHeight uint64
+	if vp.EndHeight > 0 {
+		timeoutHeight = vp.EndHeight - 1
 	}
 
-	c.previousPrevote = newPrevote
-	logger.Info().Str("tx-hash", resp.TxHash).Msg("successfully forwarded prices")
-	pricePosterCounter.WithLabelValues("true").Inc()
+	newPrevote, oldPrevote := newPrevote(prices, c.validator, c.feeder), c.revealablePrevote(vp, logger)
+	for retries := 0; ; retries++ {
+		resp, err := vote(ctx, newPrevote, oldPrevote, c.validator, c.feeder, timeoutHeight, c.deps, logger)
+		if err == nil {
+			c.previousPrevote, c.previousPeriod = newPrevote, vp.Height
+			if c.stateFile != "" {
+				if err := savePrevote(c.stateFile, newPrevote, vp.Height); err != nil {
+					logger.Err(err).Msg("failed to persist prevote")
+				}
+			}
+			logger.Info().Str("tx-hash", resp.TxHash).Msg("successfully forwarded prices")
+			pricePosterCounter.WithLabelValues("true").Inc()
+
+			resp, err = waitForInclusion(ctx, c.deps.txClient, c.deps.nodeClient, resp.TxHash, timeoutHeight)
+			if err == nil && resp.Code != abcitypes.CodeTypeOK {
+				// the sequence was consumed by the failed tx
+				c.deps.account.synced = false
+				err = fmt.Errorf("tx failed: %s", resp.RawLog)
+			}
+			if err == nil {
+				logger.Info().Str("tx-hash", resp.TxHash).Int64("height", resp.Height).Msg("vote included")
+				voteCounter.WithLabelValues("included").Inc()
+				return
+			}
+		} else {
+			logger.Err(err).Msg("prevote failed")
+			pricePosterCounter.WithLabelValues("false").Inc()
+		}
+
+		if errors.Is(err, errVoteExpired) || (resp != nil && isTimeoutHeight(resp)) {
+			logger.Warn().Msg("voting period ended before the vote was included")
+			voteCounter.WithLabelValues("expired").Inc()
+			return
+		}
+		if retries == MaxVoteRetries || ctx.Err() != nil {
+			logger.Err(err).Msg("vote failed")
+			voteCounter.WithLabelValues("failed").Inc()
+			return
+		}
+		logger.Warn().Err(err).Int("retry", retries+1).Msg("vote not included, retrying")
+	}
+}
+
+// revealablePrevote returns the previous prevote, unless it was sent before the voting period preceding
+// the given one, e.g. by a feeder restarted from an old state file, as the oracle module rejects the whole
+// tx revealing it then. The voting period is not checked when its end is unknown.
+func (c *Client) revealablePrevote(vp types.VotingPeriod, logger zerolog.Logger) *prevote {
+	if c.previousPrevote == nil || vp.EndHeight == 0 {
+		return c.previousPrevote
+	}
+	if votePeriod := vp.EndHeight - vp.Height; c.previousPeriod+votePeriod != vp.Height {
+		logger.Warn().Uint64("prevote-voting-period-height", c.previousPeriod).Msg("previous prevote is stale, discarding it")
+		return nil
+	}
+	return c.previousPrevote
 }
 
 func (c *Client) Close() {
+	if c.conn == nil {
+		return
+	}
+	if err := c.conn.Close(); err != nil {
+		c.logger.Err(err).Msg("close error")
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"testing"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -56,12 +57,15 @@ func (s *IntegrationTestSuite) SetupSuite() {
 
 	enableTLS := false
 	s.client = Dial(
-		grpcEndpoint,
+		[]string{grpcEndpoint},
 		s.cfg.ChainID,
 		enableTLS,
 		val.ClientCtx.Keyring,
 		val.ValAddress,
 		val.Address,
+		DeviationGuard{},
+		DefaultFeeConfig,
+		"",
 		zerolog.New(io.MultiWriter(os.Stderr, s.logs)))
 }
 
@@ -90,7 +94,7 @@ func (s *IntegrationTestSuite) randomPrices() []types.Price {
 	for i, assetPair := range vt.VoteTargets {
 		prices[i] = types.Price{
 			Pair:       assetPair,
-			Price:      float64(i),
+			Price:      sdkmath.LegacyNewDec(int64(i)),
 			SourceName: "test",
 			Valid:      true,
 		}
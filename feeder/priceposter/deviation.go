@@ -0,0 +1,92 @@
+package priceposter
+
+import (
+	"context"
+	"strconv"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// DeviationGuard defines how the prices about to be voted
+// are compared against the on-chain exchange rates.
+type DeviationGuard struct {
+	// MaxDeviation is the maximum deviation from the on-chain exchange rate,
+	// relative to it, tolerated for every pair. Zero disables the guard.
+	MaxDeviation float64
+	// PairMaxDeviations overrides MaxDeviation for specific pairs.
+	PairMaxDeviations map[asset.Pair]float64
+	// Abstain reports whether deviating prices are replaced by abstain
+	// votes, otherwise they are only flagged.
+	Abstain bool
+}
+
+// maxDeviation returns the max deviation tolerated for the given pair.
+func (g DeviationGuard) maxDeviation(pair asset.Pair) float64 {
+	if maxDeviation, ok := g.PairMaxDeviations[pair]; ok {
+		return maxDeviation
+	}
+	return g.MaxDeviation
+}
+
+var deviatingPricesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.PrometheusNamespace,
+	Name:      "deviating_prices_total",
+	Help:      "The total number of prices deviating from the on-chain exchange rate, by pair and abstain status",
+}, []string{"pair", "abstained"})
+
+// checkDeviations compares the valid prices against the on-chain exchange rates
+// and flags those which deviate more than tolerated by the DeviationGuard.
+// If the guard is configured to abstain, then deviating prices are returned as invalid.
+// Pairs without an on-chain exchange rate are not checked.
+func checkDeviations(ctx context.Context, oracleClient Oracle, guard DeviationGuard, prices []types.Price, logger zerolog.Logger) []types.Price {
+	log := logger.With().Str("stage", "check-deviations").Logger()
+
+	checked := make([]types.Price, len(prices))
+	for i, price := range prices {
+		checked[i] = price
+
+		maxDeviation := guard.maxDeviation(price.Pair)
+		if !price.Valid || maxDeviation <= 0 {
+			continue
+		}
+
+		resp, err := oracleClient.ExchangeRate(ctx, &oracletypes.QueryExchangeRateRequest{Pair: price.Pair})
+		if err != nil {
+			log.Debug().Err(err).Str("pair", price.Pair.String()).Msg("no on-chain exchange rate to compare with")
+			continue
+		}
+		chainRate := resp.ExchangeRate
+		if !chainRate.IsPositive() {
+			continue
+		}
+
+		deviation := price.Price.Sub(chainRate).Abs().Quo(chainRate).MustFloat64()
+		if deviation <= maxDeviation {
+			continue
+		}
+
+		log.Warn().
+			Str("pair", price.Pair.String()).
+			Str("source", price.SourceName).
+			Stringer("price", price.Price).
+			Stringer("exchange-rate", chainRate).
+			Float64("deviation", deviation).
+			Float64("max-deviation", maxDeviation).
+			Bool("abstain", guard.Abstain).
+			Msg("price deviates from on-chain exchange rate")
+		deviatingPricesCounter.WithLabelValues(price.Pair.String(), strconv.FormatBool(guard.Abstain)).Inc()
+
+		if guard.Abstain {
+			checked[i].Price = sdkmath.LegacyZeroDec()
+			checked[i].Valid = false
+		}
+	}
+	return checked
+}
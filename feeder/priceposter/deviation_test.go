@@ -0,0 +1,76 @@
+package priceposter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/types"
+	"google.golang.org/grpc"
+)
+
+var _ Oracle = (*mockOracle)(nil)
+
+type mockOracle struct {
+	exchangeRates map[asset.Pair]sdkmath.LegacyDec
+}
+
+func (m mockOracle) AggregatePrevote(context.Context, *oracletypes.QueryAggregatePrevoteRequest, ...grpc.CallOption) (*oracletypes.QueryAggregatePrevoteResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m mockOracle) ExchangeRate(_ context.Context, req *oracletypes.QueryExchangeRateRequest, _ ...grpc.CallOption) (*oracletypes.QueryExchangeRateResponse, error) {
+	rate, ok := m.exchangeRates[req.Pair]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return &oracletypes.QueryExchangeRateResponse{ExchangeRate: rate}, nil
+}
+
+func Test_checkDeviations(t *testing.T) {
+	btc, eth, atom := asset.MustNewPair("ubtc:uusd"), asset.MustNewPair("ueth:uusd"), asset.MustNewPair("uatom:uusd")
+	oracle := mockOracle{exchangeRates: map[asset.Pair]sdkmath.LegacyDec{
+		btc: sdkmath.LegacyNewDec(100_000),
+		eth: sdkmath.LegacyNewDec(4_000),
+	}}
+	prices := []types.Price{
+		{Pair: btc, Price: sdkmath.LegacyNewDec(150_000), Valid: true},
+		{Pair: eth, Price: sdkmath.LegacyNewDec(4_100), Valid: true},
+		{Pair: atom, Price: sdkmath.LegacyNewDec(10), Valid: true}, // no on-chain rate
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		checked := checkDeviations(context.Background(), oracle, DeviationGuard{}, prices, zerolog.New(io.Discard))
+		require.Equal(t, prices, checked)
+	})
+
+	t.Run("flag only", func(t *testing.T) {
+		checked := checkDeviations(context.Background(), oracle, DeviationGuard{MaxDeviation: 0.1}, prices, zerolog.New(io.Discard))
+		require.Equal(t, prices, checked)
+	})
+
+	t.Run("abstain", func(t *testing.T) {
+		guard := DeviationGuard{MaxDeviation: 0.1, Abstain: true}
+		checked := checkDeviations(context.Background(), oracle, guard, prices, zerolog.New(io.Discard))
+		require.Equal(t, types.Price{Pair: btc, Price: sdkmath.LegacyZeroDec(), Valid: false}, checked[0])
+		require.Equal(t, prices[1:], checked[1:])
+	})
+
+	t.Run("pair override", func(t *testing.T) {
+		guard := DeviationGuard{
+			MaxDeviation:      0.1,
+			PairMaxDeviations: map[asset.Pair]float64{btc: 0.6, eth: 0.01},
+			Abstain:           true,
+		}
+		checked := checkDeviations(context.Background(), oracle, guard, prices, zerolog.New(io.Discard))
+		require.Equal(t, prices[0], checked[0])
+		require.False(t, checked[1].Valid)
+		require.Equal(t, prices[2], checked[2])
+	})
+}
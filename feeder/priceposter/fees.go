@@ -0,0 +1,118 @@
+package priceposter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txservice "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+var (
+	// DefaultFeeConfig pays 3,500,000avsg for a 500,000 gas limit.
+	DefaultFeeConfig = FeeConfig{
+		Denom:         "avsg",
+		GasPrice:      sdkmath.LegacyNewDec(7),
+		GasLimit:      500_000,
+		GasAdjustment: 1.5,
+	}
+
+	// FeeBumpFactor is the factor the gas price is raised by when the chain
+	// rejects a tx for insufficient fees without telling the required fees.
+	FeeBumpFactor = sdkmath.LegacyNewDecWithPrec(15, 1)
+
+	// MaxFeeBumps is the maximum number of times a tx is resent with a raised gas price.
+	MaxFeeBumps = 3
+
+	// requiredFeesRegexp matches the fees required by the chain in the
+	// "insufficient fees; got: 3500000avsg required: 5000000avsg: insufficient fee" raw log.
+	requiredFeesRegexp = regexp.MustCompile(`required: (\S+)`)
+)
+
+// FeeConfig defines the fees and the gas of the vote transactions.
+type FeeConfig struct {
+	// Denom is the denom the fees are paid in.
+	Denom string
+	// GasPrice is the price paid per unit of gas.
+	GasPrice sdkmath.LegacyDec
+	// GasLimit is the gas limit of the transactions, unless simulated.
+	GasLimit uint64
+	// Simulate reports whether the gas limit is estimated by simulating
+	// the transactions, and multiplied by GasAdjustment.
+	Simulate      bool
+	GasAdjustment float64
+	// MaxGasPrice caps the raises of the gas price on insufficient fees, zero being uncapped.
+	MaxGasPrice sdkmath.LegacyDec
+}
+
+// fees computes the fees of the transactions, keeping the gas price
+// raised after the chain rejected a tx for insufficient fees.
+type fees struct {
+	config   FeeConfig
+	gasPrice sdkmath.LegacyDec
+}
+
+func newFees(config FeeConfig) *fees {
+	return &fees{config: config, gasPrice: config.GasPrice}
+}
+
+// amount returns the fees paid for the gas limit, rounded up.
+func (f *fees) amount(gasLimit uint64) sdk.Coins {
+	amount := f.gasPrice.MulInt(sdkmath.NewIntFromUint64(gasLimit)).Ceil().TruncateInt()
+	return sdk.NewCoins(sdk.NewCoin(f.config.Denom, amount))
+}
+
+// gasLimit returns the configured gas limit, or the adjusted gas used by the simulation of the msgs.
+func (f *fees) gasLimit(ctx context.Context, txClient TxService, txf tx.Factory, msgs ...sdk.Msg) (uint64, error) {
+	if !f.config.Simulate {
+		return f.config.GasLimit, nil
+	}
+
+	simTx, err := txf.BuildSimTx(msgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build simulation tx: %w", err)
+	}
+	resp, err := txClient.Simulate(ctx, &txservice.SimulateRequest{TxBytes: simTx})
+	if err != nil {
+		return 0, fmt.Errorf("failed to simulate tx: %w", err)
+	}
+	return uint64(math.Ceil(f.config.GasAdjustment * float64(resp.GasInfo.GasUsed))), nil
+}
+
+// bump raises the gas price after the tx was rejected for insufficient fees, to the
+// required fees if the response tells them, or by FeeBumpFactor otherwise. It reports
+// whether the tx is worth resending, which is not the case once MaxGasPrice is reached.
+func (f *fees) bump(resp *sdk.TxResponse, gasLimit uint64) bool {
+	if !isInsufficientFee(resp) {
+		return false
+	}
+
+	gasPrice := f.gasPrice.Mul(FeeBumpFactor)
+	if match := requiredFeesRegexp.FindStringSubmatch(resp.RawLog); match != nil && gasLimit > 0 {
+		required, err := sdk.ParseDecCoins(strings.TrimSuffix(match[1], ":"))
+		if err == nil && required.AmountOf(f.config.Denom).IsPositive() {
+			gasPrice = required.AmountOf(f.config.Denom).QuoInt64(int64(gasLimit))
+		}
+	}
+	if !f.config.MaxGasPrice.IsNil() && f.config.MaxGasPrice.IsPositive() && gasPrice.GT(f.config.MaxGasPrice) {
+		gasPrice = f.config.MaxGasPrice
+	}
+	if !gasPrice.GT(f.gasPrice) {
+		return false
+	}
+
+	f.gasPrice = gasPrice
+	return true
+}
+
+// isInsufficientFee reports whether the tx was rejected for insufficient fees.
+func isInsufficientFee(resp *sdk.TxResponse) bool {
+	return resp.Codespace == sdkerrors.ErrInsufficientFee.Codespace() &&
+		resp.Code == sdkerrors.ErrInsufficientFee.ABCICode()
+}
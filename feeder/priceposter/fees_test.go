@@ -0,0 +1,133 @@
+package priceposter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txservice "github.com/cosmos/cosmos-sdk/types/tx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/app"
+	"google.golang.org/grpc"
+)
+
+var _ TxService = (*mockTxService)(nil)
+
+type mockTxService struct {
+	gasUsed   uint64
+	simulated [][]byte
+
+	// responses are returned by the successive broadcasts, a nil one being an error
+	responses   []*sdk.TxResponse
+	broadcasted [][]byte
+
+	// included are the responses of the txs included in a block, by hash
+	included map[string]*sdk.TxResponse
+}
+
+func (m *mockTxService) GetTx(_ context.Context, req *txservice.GetTxRequest, _ ...grpc.CallOption) (*txservice.GetTxResponse, error) {
+	resp, ok := m.included[req.Hash]
+	if !ok {
+		return nil, fmt.Errorf("tx not found: %s", req.Hash)
+	}
+	return &txservice.GetTxResponse{TxResponse: resp}, nil
+}
+
+func (m *mockTxService) BroadcastTx(_ context.Context, req *txservice.BroadcastTxRequest, _ ...grpc.CallOption) (*txservice.BroadcastTxResponse, error) {
+	m.broadcasted = append(m.broadcasted, req.TxBytes)
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	if resp == nil {
+		return nil, fmt.Errorf("connection lost")
+	}
+	return &txservice.BroadcastTxResponse{TxResponse: resp}, nil
+}
+
+func (m *mockTxService) Simulate(_ context.Context, req *txservice.SimulateRequest, _ ...grpc.CallOption) (*txservice.SimulateResponse, error) {
+	m.simulated = append(m.simulated, req.TxBytes)
+	return &txservice.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: m.gasUsed}}, nil
+}
+
+func Test_fees_amount(t *testing.T) {
+	f := newFees(DefaultFeeConfig)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("avsg", 3_500_000)), f.amount(500_000))
+
+	f = newFees(FeeConfig{Denom: "avsg", GasPrice: sdkmath.LegacyMustNewDecFromStr("0.025")})
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("avsg", 3)), f.amount(101), "fees are rounded up")
+}
+
+func Test_fees_gasLimit(t *testing.T) {
+	encoding := app.MakeEncodingConfig()
+	kb := keyring.NewInMemory(encoding.Codec)
+	record, _, err := kb.NewMnemonic("feeder", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+	require.NoError(t, err)
+	addr, err := record.GetAddress()
+	require.NoError(t, err)
+
+	txf := tx.Factory{}.
+		WithChainID("vsc-localnet-0").
+		WithKeybase(kb).
+		WithFromName("feeder").
+		WithTxConfig(encoding.TxConfig)
+	msg := banktypes.NewMsgSend(addr, addr, sdk.NewCoins(sdk.NewInt64Coin("avsg", 1)))
+	txClient := &mockTxService{gasUsed: 100_001}
+
+	gasLimit, err := newFees(DefaultFeeConfig).gasLimit(context.Background(), txClient, txf, msg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(500_000), gasLimit)
+	require.Empty(t, txClient.simulated)
+
+	config := DefaultFeeConfig
+	config.Simulate = true
+	gasLimit, err = newFees(config).gasLimit(context.Background(), txClient, txf, msg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(150_002), gasLimit)
+	require.Len(t, txClient.simulated, 1)
+}
+
+func Test_fees_bump(t *testing.T) {
+	insufficientFee := func(rawLog string) *sdk.TxResponse {
+		return &sdk.TxResponse{
+			Codespace: sdkerrors.ErrInsufficientFee.Codespace(),
+			Code:      sdkerrors.ErrInsufficientFee.ABCICode(),
+			RawLog:    rawLog,
+		}
+	}
+
+	t.Run("required fees", func(t *testing.T) {
+		f := newFees(DefaultFeeConfig)
+		require.True(t, f.bump(insufficientFee("insufficient fees; got: 3500000avsg required: 5000000avsg: insufficient fee"), 500_000))
+		require.Equal(t, sdkmath.LegacyNewDec(10), f.gasPrice)
+		require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("avsg", 5_000_000)), f.amount(500_000))
+		// the raised gas price is kept for the next txs
+		require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("avsg", 1_000_000)), f.amount(100_000))
+	})
+
+	t.Run("unknown required fees", func(t *testing.T) {
+		f := newFees(DefaultFeeConfig)
+		require.True(t, f.bump(insufficientFee("insufficient fee"), 500_000))
+		require.Equal(t, sdkmath.LegacyMustNewDecFromStr("10.5"), f.gasPrice)
+	})
+
+	t.Run("max gas price", func(t *testing.T) {
+		config := DefaultFeeConfig
+		config.MaxGasPrice = sdkmath.LegacyNewDec(8)
+		f := newFees(config)
+		require.True(t, f.bump(insufficientFee("insufficient fees; got: 3500000avsg required: 5000000avsg: insufficient fee"), 500_000))
+		require.Equal(t, sdkmath.LegacyNewDec(8), f.gasPrice)
+		require.False(t, f.bump(insufficientFee("insufficient fees; got: 4000000avsg required: 5000000avsg: insufficient fee"), 500_000))
+	})
+
+	t.Run("other failure", func(t *testing.T) {
+		f := newFees(DefaultFeeConfig)
+		require.False(t, f.bump(&sdk.TxResponse{Codespace: "oracle", Code: 13, RawLog: "required: 5000000avsg"}, 500_000))
+		require.Equal(t, DefaultFeeConfig.GasPrice, f.gasPrice)
+	})
+}
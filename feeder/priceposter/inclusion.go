@@ -0,0 +1,73 @@
+package priceposter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txservice "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
+)
+
+var (
+	// InclusionPollInterval is the interval between the checks for the inclusion of a vote tx.
+	InclusionPollInterval = 1 * time.Second
+
+	// MaxVoteRetries is the maximum number of times a vote which failed is resent
+	// within its voting period.
+	MaxVoteRetries = 2
+
+	// errVoteExpired is returned when the voting period of a vote tx ended before its inclusion.
+	errVoteExpired = errors.New("voting period ended before the vote was included")
+)
+
+var voteCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.PrometheusNamespace,
+	Name:      "votes_total",
+	Help:      "The total number of votes by status: included in a block, failed or expired with their voting period",
+}, []string{"status"})
+
+// waitForInclusion polls the tx until it is included in a block, and returns its
+// response. When timeoutHeight is set, the tx can no longer be included once the
+// chain reached it, and errVoteExpired is returned.
+func waitForInclusion(ctx context.Context, txClient TxService, nodeClient Node, txHash string, timeoutHeight uint64) (*sdk.TxResponse, error) {
+	var txResp *sdk.TxResponse
+	expired := false
+	err := tryUntilDone(ctx, InclusionPollInterval, func() error {
+		// the height is queried first, so a tx not found at the timeout height will never be
+		var height int64
+		if timeoutHeight > 0 {
+			block, err := nodeClient.GetLatestBlock(ctx, &cmtservice.GetLatestBlockRequest{})
+			if err != nil {
+				return err
+			}
+			height = block.GetSdkBlock().GetHeader().Height
+		}
+
+		resp, err := txClient.GetTx(ctx, &txservice.GetTxRequest{Hash: txHash})
+		if err == nil {
+			txResp = resp.TxResponse
+			return nil
+		}
+		if timeoutHeight > 0 && height >= int64(timeoutHeight) {
+			expired = true
+			return nil
+		}
+		return err
+	})
+	if expired {
+		return nil, errVoteExpired
+	}
+	return txResp, err
+}
+
+// isTimeoutHeight reports whether the tx was rejected for being past its timeout height.
+func isTimeoutHeight(resp *sdk.TxResponse) bool {
+	return resp.Codespace == sdkerrors.ErrTxTimeoutHeight.Codespace() &&
+		resp.Code == sdkerrors.ErrTxTimeoutHeight.ABCICode()
+}
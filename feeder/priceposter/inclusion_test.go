@@ -0,0 +1,61 @@
+package priceposter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+var _ Node = (*mockNode)(nil)
+
+type mockNode struct {
+	height int64
+}
+
+func (m *mockNode) GetLatestBlock(context.Context, *cmtservice.GetLatestBlockRequest, ...grpc.CallOption) (*cmtservice.GetLatestBlockResponse, error) {
+	// every poll sees a new block
+	m.height++
+	return &cmtservice.GetLatestBlockResponse{SdkBlock: &cmtservice.Block{Header: cmtservice.Header{Height: m.height}}}, nil
+}
+
+func Test_waitForInclusion(t *testing.T) {
+	InclusionPollInterval = time.Millisecond
+	defer func() { InclusionPollInterval = 1 * time.Second }()
+
+	included := &sdk.TxResponse{TxHash: "included", Height: 12}
+	failed := &sdk.TxResponse{TxHash: "failed", Height: 12, Code: 5, RawLog: "insufficient funds"}
+	txClient := &mockTxService{included: map[string]*sdk.TxResponse{"included": included, "failed": failed}}
+
+	t.Run("included", func(t *testing.T) {
+		resp, err := waitForInclusion(context.Background(), txClient, &mockNode{height: 10}, "included", 19)
+		require.NoError(t, err)
+		require.Equal(t, included, resp)
+	})
+
+	t.Run("failed in block", func(t *testing.T) {
+		resp, err := waitForInclusion(context.Background(), txClient, &mockNode{height: 10}, "failed", 19)
+		require.NoError(t, err)
+		require.Equal(t, failed, resp)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		node := &mockNode{height: 10}
+		_, err := waitForInclusion(context.Background(), txClient, node, "dropped", 19)
+		require.ErrorIs(t, err, errVoteExpired)
+		require.Equal(t, int64(19), node.height)
+	})
+
+	t.Run("no timeout height", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		node := &mockNode{}
+		_, err := waitForInclusion(ctx, txClient, node, "dropped", 0)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Zero(t, node.height)
+	})
+}
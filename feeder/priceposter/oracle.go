@@ -4,28 +4,39 @@ import (
 	"context"
 	"crypto/rand"
 	"math/big"
-	"strconv"
-	"strings"
 
 	sdkmath "cosmossdk.io/math"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
 	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
 	"github.com/vsc-blockchain/pricefeeder/types"
 )
 
 var (
-	// MaxSaltNumber is the maximum salt number we can use for randomness.
-	// NOTE: max length of the salt is 4.
-	// TODO(mercilex): if we used digits + alphanumerics it's more randomized
-	MaxSaltNumber = big.NewInt(9999) // NOTE(mercilex): max salt length is 4
+	// MaxSaltLength is the maximum length of a salt accepted by the oracle module, which rejects
+	// longer salts in MsgAggregateExchangeRateVote.ValidateBasic (x/oracle/types/msgs.go in core).
+	MaxSaltLength = 4
+
+	// saltAlphabet are the characters of the salts, the hex digits ValidateBasic accepts,
+	// which gives 16^4 possible salts instead of the 10^4 of a decimal number.
+	saltAlphabet = "0123456789abcdef"
 )
 
+var abstentionsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.PrometheusNamespace,
+	Name:      "abstentions_total",
+	Help:      "The total number of abstain votes, by pair",
+}, []string{"pair"})
+
 func vote(
 	ctx context.Context,
 	newPrevote, oldPrevote *prevote,
 	validator sdk.ValAddress,
 	feeder sdk.AccAddress,
+	timeoutHeight uint64,
 	deps deps,
 	logger zerolog.Logger,
 ) (txResponse *sdk.TxResponse, err error) {
@@ -51,7 +62,7 @@ func vote(
 
 	return sendTx(
 		ctx, deps.keyBase, deps.authClient, deps.txClient,
-		feeder, deps.txConfig, deps.ir, deps.chainID, msgs...,
+		feeder, deps.txConfig, deps.ir, deps.chainID, deps.account, deps.fees, timeoutHeight, logger, msgs...,
 	)
 }
 
@@ -94,12 +105,14 @@ type prevote struct {
 	vote string
 }
 
+// newPrevote returns the prevote of the given prices. Invalid prices are voted
+// as abstentions, whatever their price.
 func newPrevote(prices []types.Price, validator sdk.ValAddress, feeder sdk.AccAddress) *prevote {
 	tuple := make(oracletypes.ExchangeRateTuples, len(prices))
 	for i, price := range prices {
 		tuple[i] = oracletypes.ExchangeRateTuple{
 			Pair:         price.Pair,
-			ExchangeRate: float64ToDec(price.Price),
+			ExchangeRate: exchangeRate(price),
 		}
 	}
 
@@ -107,11 +120,7 @@ func newPrevote(prices []types.Price, validator sdk.ValAddress, feeder sdk.AccAd
 	if err != nil {
 		panic(err)
 	}
-	nBig, err := rand.Int(rand.Reader, MaxSaltNumber)
-	if err != nil {
-		panic(err)
-	}
-	salt := nBig.String()
+	salt := newSalt()
 
 	hash := oracletypes.GetAggregateVoteHash(salt, votesStr, validator)
 
@@ -122,27 +131,27 @@ func newPrevote(prices []types.Price, validator sdk.ValAddress, feeder sdk.AccAd
 	}
 }
 
-func float64ToDec(price float64) sdkmath.LegacyDec {
-	// TODO(mercilex): precision for numbers with a lot of decimal digits
-	formattedPrice := strconv.FormatFloat(price, 'f', -1, 64)
-
-	parts := strings.Split(formattedPrice, ".")
-	intPart := parts[0]
-	decPart := ""
-
-	if len(parts) > 1 {
-		decPart = parts[1]
-	}
-
-	if len(decPart) > 18 {
-		decPart = decPart[:18]
+// newSalt returns a salt of MaxSaltLength characters drawn uniformly from saltAlphabet.
+func newSalt() string {
+	alphabetSize := big.NewInt(int64(len(saltAlphabet)))
+	salt := make([]byte, MaxSaltLength)
+	for i := range salt {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			panic(err)
+		}
+		salt[i] = saltAlphabet[n.Int64()]
 	}
+	return string(salt)
+}
 
-	if decPart == "" {
-		formattedPrice = intPart
-	} else {
-		formattedPrice = intPart + "." + decPart
+// exchangeRate returns the exchange rate voted for the price. The oracle module
+// counts a zero exchange rate as an abstention, which is voted for the prices which
+// are not valid or not positive, so that they never end up in the ballot.
+func exchangeRate(price types.Price) sdkmath.LegacyDec {
+	if !price.Valid || price.Price.IsNil() || !price.Price.IsPositive() {
+		abstentionsCounter.WithLabelValues(price.Pair.String()).Inc()
+		return sdkmath.LegacyZeroDec()
 	}
-
-	return sdkmath.LegacyMustNewDecFromStr(formattedPrice)
+	return price.Price
 }
@@ -0,0 +1,97 @@
+package priceposter
+
+import (
+	"strings"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func Test_newSalt(t *testing.T) {
+	salts := map[string]struct{}{}
+	for i := 0; i < 1000; i++ {
+		salt := newSalt()
+		require.Len(t, salt, MaxSaltLength)
+		for _, c := range salt {
+			require.True(t, strings.ContainsRune(saltAlphabet, c), "unexpected character %q", c)
+		}
+		salts[salt] = struct{}{}
+	}
+	// 1000 salts out of 16^4 are very unlikely to collide more than a few dozen times
+	require.Greater(t, len(salts), 950)
+}
+
+func Test_newPrevote_ValidVote(t *testing.T) {
+	validator := sdk.ValAddress(make([]byte, 20))
+	feeder := sdk.AccAddress(make([]byte, 20))
+	prices := []types.Price{{Pair: asset.MustNewPair("ubtc:uusd"), Price: sdkmath.LegacyNewDec(100_000), Valid: true}}
+
+	// the salts are accepted by the oracle module once revealed
+	for i := 0; i < 100; i++ {
+		p := newPrevote(prices, validator, feeder)
+		msg := oracletypes.MsgAggregateExchangeRateVote{
+			Salt:          p.salt,
+			ExchangeRates: p.vote,
+			Feeder:        feeder.String(),
+			Validator:     validator.String(),
+		}
+		require.NoError(t, msg.ValidateBasic(), "salt %q", p.salt)
+	}
+}
+
+func Test_newPrevote(t *testing.T) {
+	validator := sdk.ValAddress(make([]byte, 20))
+	feeder := sdk.AccAddress(make([]byte, 20))
+	prices := []types.Price{
+		{Pair: asset.MustNewPair("ubtc:uusd"), Price: sdkmath.LegacyMustNewDecFromStr("100000.5"), Valid: true},
+		{Pair: asset.MustNewPair("ueth:uusd"), Price: sdkmath.LegacyNewDec(4_000), Valid: true},
+	}
+
+	p := newPrevote(prices, validator, feeder)
+	require.Len(t, p.salt, MaxSaltLength)
+	require.Equal(t, oracletypes.GetAggregateVoteHash(p.salt, p.vote, validator).String(), p.msg.Hash)
+
+	tuples := oracletypes.ExchangeRateTuples{
+		{Pair: prices[0].Pair, ExchangeRate: prices[0].Price},
+		{Pair: prices[1].Pair, ExchangeRate: prices[1].Price},
+	}
+	vote, err := tuples.ToString()
+	require.NoError(t, err)
+	require.Equal(t, vote, p.vote)
+}
+
+func Test_newPrevote_Abstain(t *testing.T) {
+	validator := sdk.ValAddress(make([]byte, 20))
+	feeder := sdk.AccAddress(make([]byte, 20))
+	btc, eth, atom := asset.MustNewPair("ubtc:uusd"), asset.MustNewPair("ueth:uusd"), asset.MustNewPair("uatom:uusd")
+	prices := []types.Price{
+		{Pair: btc, Price: sdkmath.LegacyNewDec(100_000), Valid: true},
+		// a stale price is never voted, whatever its value
+		{Pair: eth, Price: sdkmath.LegacyNewDec(4_000), Valid: false},
+		// unknown pairs have no price at all
+		{Pair: atom, Valid: false},
+	}
+	btcAbstentions := testutil.ToFloat64(abstentionsCounter.WithLabelValues(btc.String()))
+	ethAbstentions := testutil.ToFloat64(abstentionsCounter.WithLabelValues(eth.String()))
+	atomAbstentions := testutil.ToFloat64(abstentionsCounter.WithLabelValues(atom.String()))
+
+	p := newPrevote(prices, validator, feeder)
+
+	tuples := oracletypes.ExchangeRateTuples{
+		{Pair: btc, ExchangeRate: sdkmath.LegacyNewDec(100_000)},
+		{Pair: eth, ExchangeRate: sdkmath.LegacyZeroDec()},
+		{Pair: atom, ExchangeRate: sdkmath.LegacyZeroDec()},
+	}
+	vote, err := tuples.ToString()
+	require.NoError(t, err)
+	require.Equal(t, vote, p.vote)
+	require.Equal(t, btcAbstentions, testutil.ToFloat64(abstentionsCounter.WithLabelValues(btc.String())))
+	require.Equal(t, ethAbstentions+1, testutil.ToFloat64(abstentionsCounter.WithLabelValues(eth.String())))
+	require.Equal(t, atomAbstentions+1, testutil.ToFloat64(abstentionsCounter.WithLabelValues(atom.String())))
+}
@@ -10,8 +10,10 @@ import (
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	txservice "github.com/cosmos/cosmos-sdk/types/tx"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/rs/zerolog"
 	coretypes "github.com/vsc-blockchain/core/types"
 )
 
@@ -24,6 +26,10 @@ func sendTx(
 	txConfig client.TxConfig,
 	ir codectypes.InterfaceRegistry,
 	chainID string,
+	account *account,
+	fees *fees,
+	timeoutHeight uint64,
+	logger zerolog.Logger,
 	msgs ...sdk.Msg,
 ) (*sdk.TxResponse, error) {
 	// get key from keybase, can't fail
@@ -32,35 +38,87 @@ func sendTx(
 		panic(err)
 	}
 
-	// set msgs, can't fail
-	txBuilder := txConfig.NewTxBuilder()
-	err = txBuilder.SetMsgs(msgs...)
-	if err != nil {
-		panic(err)
+	// get acc info once, then track the sequence locally, can fail
+	if !account.synced {
+		if err := account.sync(ctx, authClient, ir, feeder); err != nil {
+			return nil, err
+		}
 	}
 
-	txBuilder.SetFeeAmount(sdk.NewCoins(sdk.NewInt64Coin("avsg", 3_500_000)))
-	txBuilder.SetGasLimit(500_000)
+	txFactory := tx.Factory{}.
+		WithChainID(chainID).
+		WithKeybase(keyBase).
+		WithFromName(keyInfo.Name).
+		WithTxConfig(txConfig).
+		WithAccountNumber(account.number).
+		WithSequence(account.sequence).
+		WithTimeoutHeight(timeoutHeight)
 
-	// get acc info, can fail
-	accNum, sequence, err := getAccount(ctx, authClient, ir, feeder)
+	gasLimit, err := fees.gasLimit(ctx, txClient, txFactory, msgs...)
 	if err != nil {
 		return nil, err
 	}
 
-	txFactory := tx.Factory{}.
-		WithChainID(chainID).
-		WithKeybase(keyBase).
-		WithTxConfig(txConfig).
-		WithAccountNumber(accNum).
-		WithSequence(sequence)
+	resynced := false
+	for bumps := 0; ; {
+		txFactory = txFactory.WithAccountNumber(account.number).WithSequence(account.sequence)
+		resp, err := signAndBroadcast(ctx, txFactory, txClient, txConfig, keyInfo.Name, fees.amount(gasLimit), gasLimit, timeoutHeight, msgs...)
+		if err != nil {
+			// the tx might have reached the mempool
+			account.synced = false
+			return nil, err
+		}
+		if resp.Code == abcitypes.CodeTypeOK {
+			account.sequence++
+			return resp, nil
+		}
 
-	// sign tx, can't fail
-	err = tx.Sign(ctx, txFactory, keyInfo.Name, txBuilder, true)
+		// another tx of the feeder was included, re-sync and retry once
+		if isSequenceMismatch(resp) && !resynced {
+			resynced = true
+			logger.Warn().Str("raw-log", resp.RawLog).Uint64("sequence", account.sequence).Msg("account sequence mismatch, re-syncing")
+			if err := account.sync(ctx, authClient, ir, feeder); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if bumps == MaxFeeBumps || !fees.bump(resp, gasLimit) {
+			return resp, fmt.Errorf("tx failed: %s", resp.RawLog)
+		}
+		bumps++
+		logger.Warn().Str("raw-log", resp.RawLog).Str("gas-price", fees.gasPrice.String()).Msg("insufficient fees, raised the gas price")
+	}
+}
+
+func signAndBroadcast(
+	ctx context.Context,
+	txFactory tx.Factory,
+	txClient TxService,
+	txConfig client.TxConfig,
+	keyName string,
+	feeAmount sdk.Coins,
+	gasLimit uint64,
+	timeoutHeight uint64,
+	msgs ...sdk.Msg,
+) (*sdk.TxResponse, error) {
+	// set msgs, can't fail
+	txBuilder := txConfig.NewTxBuilder()
+	err := txBuilder.SetMsgs(msgs...)
 	if err != nil {
 		panic(err)
 	}
 
+	txBuilder.SetFeeAmount(feeAmount)
+	txBuilder.SetGasLimit(gasLimit)
+	txBuilder.SetTimeoutHeight(timeoutHeight)
+
+	// sign tx, can fail with remote signers
+	err = tx.Sign(ctx, txFactory, keyName, txBuilder, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign tx: %w", err)
+	}
+
 	txBytes, err := txConfig.TxEncoder()(txBuilder.GetTx())
 	if err != nil {
 		panic(err)
@@ -73,12 +131,34 @@ func sendTx(
 	if err != nil {
 		return nil, err
 	}
-	if resp.TxResponse.Code != abcitypes.CodeTypeOK {
-		return resp.TxResponse, fmt.Errorf("tx failed: %s", resp.TxResponse.RawLog)
-	}
 	return resp.TxResponse, nil
 }
 
+// account caches the account number and the sequence of the feeder,
+// the sequence being incremented locally after every successful broadcast.
+type account struct {
+	synced   bool
+	number   uint64
+	sequence uint64
+}
+
+// sync queries the account number and the sequence of the feeder.
+func (a *account) sync(ctx context.Context, authClient Auth, ir codectypes.InterfaceRegistry, feeder sdk.AccAddress) error {
+	number, sequence, err := getAccount(ctx, authClient, ir, feeder)
+	if err != nil {
+		a.synced = false
+		return err
+	}
+	a.synced, a.number, a.sequence = true, number, sequence
+	return nil
+}
+
+// isSequenceMismatch reports whether the tx was rejected for an unexpected sequence.
+func isSequenceMismatch(resp *sdk.TxResponse) bool {
+	return resp.Codespace == sdkerrors.ErrWrongSequence.Codespace() &&
+		resp.Code == sdkerrors.ErrWrongSequence.ABCICode()
+}
+
 func getAccount(ctx context.Context, authClient Auth, ir codectypes.InterfaceRegistry, feeder sdk.AccAddress) (uint64, uint64, error) {
 	accRaw, err := authClient.Account(ctx, &authtypes.QueryAccountRequest{Address: feeder.String()})
 	if err != nil {
@@ -0,0 +1,116 @@
+package priceposter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/app"
+	"google.golang.org/grpc"
+)
+
+var _ Auth = (*mockAuth)(nil)
+
+type mockAuth struct {
+	calls int
+}
+
+func (m *mockAuth) Account(context.Context, *authtypes.QueryAccountRequest, ...grpc.CallOption) (*authtypes.QueryAccountResponse, error) {
+	m.calls++
+	return nil, fmt.Errorf("node unavailable")
+}
+
+func Test_sendTx(t *testing.T) {
+	encoding := app.MakeEncodingConfig()
+	banktypes.RegisterInterfaces(encoding.InterfaceRegistry)
+	kb := keyring.NewInMemory(encoding.Codec)
+	record, _, err := kb.NewMnemonic("feeder", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+	require.NoError(t, err)
+	feeder, err := record.GetAddress()
+	require.NoError(t, err)
+	msg := banktypes.NewMsgSend(feeder, feeder, sdk.NewCoins(sdk.NewInt64Coin("avsg", 1)))
+
+	ok := &sdk.TxResponse{TxHash: "ok"}
+	sequenceMismatch := &sdk.TxResponse{
+		Codespace: sdkerrors.ErrWrongSequence.Codespace(),
+		Code:      sdkerrors.ErrWrongSequence.ABCICode(),
+		RawLog:    "account sequence mismatch, expected 7, got 6: incorrect account sequence",
+	}
+	insufficientFee := &sdk.TxResponse{
+		Codespace: sdkerrors.ErrInsufficientFee.Codespace(),
+		Code:      sdkerrors.ErrInsufficientFee.ABCICode(),
+		RawLog:    "insufficient fees; got: 3500000avsg required: 5000000avsg: insufficient fee",
+	}
+
+	// sequence returns the sequence and the fees of the broadcasted tx
+	sequence := func(txBytes []byte) (uint64, sdk.Coins) {
+		tx, err := encoding.TxConfig.TxDecoder()(txBytes)
+		require.NoError(t, err)
+		sigs, err := tx.(authsigning.SigVerifiableTx).GetSignaturesV2()
+		require.NoError(t, err)
+		return sigs[0].Sequence, tx.(sdk.FeeTx).GetFee()
+	}
+
+	auth := new(mockAuth)
+	acc := &account{synced: true, number: 1, sequence: 5}
+	fees := newFees(DefaultFeeConfig)
+	send := func(responses ...*sdk.TxResponse) (*mockTxService, error) {
+		txClient := &mockTxService{responses: responses}
+		_, err := sendTx(
+			context.Background(), kb, auth, txClient, feeder, encoding.TxConfig, encoding.InterfaceRegistry,
+			"vsc-localnet-0", acc, fees, 0, zerolog.Nop(), msg,
+		)
+		require.Empty(t, txClient.responses)
+		return txClient, err
+	}
+
+	t.Run("sequence is incremented locally", func(t *testing.T) {
+		for _, expected := range []uint64{5, 6} {
+			txClient, err := send(ok)
+			require.NoError(t, err)
+			seq, _ := sequence(txClient.broadcasted[0])
+			require.Equal(t, expected, seq)
+		}
+		require.Equal(t, uint64(7), acc.sequence)
+		require.Zero(t, auth.calls)
+	})
+
+	t.Run("insufficient fees keep the sequence", func(t *testing.T) {
+		txClient, err := send(insufficientFee, ok)
+		require.NoError(t, err)
+		seq0, fee0 := sequence(txClient.broadcasted[0])
+		seq1, fee1 := sequence(txClient.broadcasted[1])
+		require.Equal(t, uint64(7), seq0)
+		require.Equal(t, seq0, seq1)
+		require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("avsg", 3_500_000)), fee0)
+		require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("avsg", 5_000_000)), fee1)
+		require.Equal(t, uint64(8), acc.sequence)
+	})
+
+	t.Run("sequence mismatch re-syncs", func(t *testing.T) {
+		_, err := send(sequenceMismatch)
+		require.ErrorContains(t, err, "node unavailable")
+		require.Equal(t, 1, auth.calls)
+		require.False(t, acc.synced)
+	})
+
+	t.Run("broadcast error requires a re-sync", func(t *testing.T) {
+		acc.synced = true
+		_, err := send(nil)
+		require.ErrorContains(t, err, "connection lost")
+		require.False(t, acc.synced)
+
+		_, err = send()
+		require.ErrorContains(t, err, "node unavailable")
+		require.Equal(t, 2, auth.calls)
+	})
+}
@@ -0,0 +1,106 @@
+package priceposter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+)
+
+// prevoteState is the last prevote persisted to the state file,
+// so that it can be revealed after a restart.
+type prevoteState struct {
+	Salt   string `json:"salt"`
+	Vote   string `json:"vote"`
+	Height uint64 `json:"height"`
+}
+
+// savePrevote writes the prevote sent in the voting period of the given height to the state file.
+// The file is replaced atomically so that a crash never leaves a partial state.
+func savePrevote(path string, p *prevote, height uint64) error {
+	b, err := json.Marshal(prevoteState{Salt: p.salt, Vote: p.vote, Height: height})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadPrevote reads the prevote of the state file, or returns nil if there is none.
+func loadPrevote(path string, validator sdk.ValAddress, feeder sdk.AccAddress) (*prevote, uint64, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var state prevoteState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, 0, fmt.Errorf("invalid state file: %w", err)
+	}
+	hash := oracletypes.GetAggregateVoteHash(state.Salt, state.Vote, validator)
+	return &prevote{
+		msg:  oracletypes.NewMsgAggregateExchangeRatePrevote(hash, feeder, validator),
+		salt: state.Salt,
+		vote: state.Vote,
+	}, state.Height, nil
+}
+
+// recoverPrevote returns the prevote of the state file, along with the height of the voting period
+// it was sent in, if it is still the prevote of the validator on chain, so that it can be revealed
+// in the first voting period after a restart. It is discarded then if that voting period is not the
+// next one, see Client.revealablePrevote.
+func recoverPrevote(path string, oracleClient Oracle, validator sdk.ValAddress, feeder sdk.AccAddress, logger zerolog.Logger) (*prevote, uint64) {
+	if path == "" {
+		return nil, 0
+	}
+	log := logger.With().Str("stage", "recover-prevote").Str("state-file", path).Logger()
+
+	p, height, err := loadPrevote(path, validator, feeder)
+	if err != nil {
+		log.Err(err).Msg("failed to load prevote")
+		return nil, 0
+	}
+	if p == nil {
+		return nil, 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := oracleClient.AggregatePrevote(ctx, &oracletypes.QueryAggregatePrevoteRequest{
+		ValidatorAddr: validator.String(),
+	})
+	if err != nil {
+		// the prevote is checked against the chain again before being revealed
+		log.Warn().Err(err).Msg("failed to get aggregate prevote from chain, keeping the persisted prevote")
+		return p, height
+	}
+	if resp.AggregatePrevote.Hash != p.msg.Hash {
+		log.Info().Uint64("height", height).Msg("persisted prevote is not the prevote on chain, discarding it")
+		return nil, 0
+	}
+
+	log.Info().Uint64("height", height).Msg("recovered prevote")
+	return p, height
+}
@@ -0,0 +1,90 @@
+package priceposter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/x/common/asset"
+	oracletypes "github.com/vsc-blockchain/core/x/oracle/types"
+	"github.com/vsc-blockchain/pricefeeder/types"
+	"google.golang.org/grpc"
+)
+
+// mockPrevoteOracle returns the given aggregate prevote hash.
+type mockPrevoteOracle struct {
+	mockOracle
+	hash string
+}
+
+func (m mockPrevoteOracle) AggregatePrevote(context.Context, *oracletypes.QueryAggregatePrevoteRequest, ...grpc.CallOption) (*oracletypes.QueryAggregatePrevoteResponse, error) {
+	return &oracletypes.QueryAggregatePrevoteResponse{
+		AggregatePrevote: oracletypes.AggregateExchangeRatePrevote{Hash: m.hash},
+	}, nil
+}
+
+func Test_recoverPrevote(t *testing.T) {
+	validator := sdk.ValAddress(make([]byte, 20))
+	feeder := sdk.AccAddress(make([]byte, 20))
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	p := newPrevote([]types.Price{{Pair: asset.MustNewPair("ubtc:uusd"), Price: sdkmath.LegacyNewDec(100_000), Valid: true}}, validator, feeder)
+	require.NoError(t, savePrevote(path, p, 100))
+
+	loaded, height, err := loadPrevote(path, validator, feeder)
+	require.NoError(t, err)
+	require.Equal(t, p, loaded)
+	require.Equal(t, uint64(100), height)
+
+	t.Run("prevote on chain", func(t *testing.T) {
+		recovered, height := recoverPrevote(path, mockPrevoteOracle{hash: p.msg.Hash}, validator, feeder, zerolog.Nop())
+		require.Equal(t, p, recovered)
+		require.Equal(t, uint64(100), height)
+	})
+
+	t.Run("other prevote on chain", func(t *testing.T) {
+		other := newPrevote([]types.Price{{Pair: asset.MustNewPair("ubtc:uusd"), Price: sdkmath.LegacyNewDec(99_000), Valid: true}}, validator, feeder)
+		recovered, _ := recoverPrevote(path, mockPrevoteOracle{hash: other.msg.Hash}, validator, feeder, zerolog.Nop())
+		require.Nil(t, recovered)
+	})
+
+	t.Run("chain unavailable", func(t *testing.T) {
+		recovered, height := recoverPrevote(path, mockOracle{}, validator, feeder, zerolog.Nop())
+		require.Equal(t, p, recovered)
+		require.Equal(t, uint64(100), height)
+	})
+
+	t.Run("no state", func(t *testing.T) {
+		recovered, _ := recoverPrevote(filepath.Join(t.TempDir(), "state.json"), mockOracle{}, validator, feeder, zerolog.Nop())
+		require.Nil(t, recovered)
+		recovered, _ = recoverPrevote("", mockOracle{}, validator, feeder, zerolog.Nop())
+		require.Nil(t, recovered)
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+		recovered, _ := recoverPrevote(path, mockOracle{}, validator, feeder, zerolog.Nop())
+		require.Nil(t, recovered)
+	})
+}
+
+func TestClient_revealablePrevote(t *testing.T) {
+	validator := sdk.ValAddress(make([]byte, 20))
+	feeder := sdk.AccAddress(make([]byte, 20))
+	p := newPrevote([]types.Price{{Pair: asset.MustNewPair("ubtc:uusd"), Price: sdkmath.LegacyNewDec(100_000), Valid: true}}, validator, feeder)
+	// the prevote was sent in the voting period starting at 100, e.g. before a restart
+	c := &Client{previousPrevote: p, previousPeriod: 100}
+
+	require.Equal(t, p, c.revealablePrevote(types.VotingPeriod{Height: 110, EndHeight: 120}, zerolog.Nop()))
+	require.Nil(t, c.revealablePrevote(types.VotingPeriod{Height: 120, EndHeight: 130}, zerolog.Nop()))
+	require.Nil(t, c.revealablePrevote(types.VotingPeriod{Height: 1000, EndHeight: 1010}, zerolog.Nop()))
+	// the voting period is not checked without its end
+	require.Equal(t, p, c.revealablePrevote(types.VotingPeriod{Height: 1000}, zerolog.Nop()))
+
+	require.Nil(t, (&Client{}).revealablePrevote(types.VotingPeriod{Height: 110, EndHeight: 120}, zerolog.Nop()))
+}
@@ -1,14 +1,17 @@
 package priceprovider
 
 import (
+	"bytes"
 	"encoding/json"
-	"math"
-	"sort"
+	"reflect"
+	"sync"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/asset"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/aggregation"
 	"github.com/vsc-blockchain/pricefeeder/metrics"
 	"github.com/vsc-blockchain/pricefeeder/types"
 )
@@ -18,28 +21,145 @@ var _ types.PriceProvider = (*AggregatePriceProvider)(nil)
 // AggregatePriceProvider aggregates multiple price providers
 // and queries them for prices.
 type AggregatePriceProvider struct {
-	logger    zerolog.Logger
-	providers map[int]types.PriceProvider // we use a map here to provide random ranging (since golang's map range is unordered)
+	logger          zerolog.Logger
+	mu              sync.RWMutex
+	providers       map[int]types.PriceProvider // we use a map here to provide random ranging (since golang's map range is unordered)
+	aggregator      aggregation.Aggregator      // default aggregator
+	pairAggregators map[asset.Pair]aggregation.Aggregator
+	reloadMutex     sync.Mutex
+	closed          bool                     // guarded by reloadMutex, no source is started once closed
+	sources         map[string]runningSource // the running sources by name, compared against on reload
+	newProvider     func(sourceName string, pairToSymbolMap map[asset.Pair]types.Symbol, config json.RawMessage, logger zerolog.Logger) types.PriceProvider
+}
+
+// runningSource is a PriceProvider along with the settings it was started with.
+type runningSource struct {
+	provider        types.PriceProvider
+	pairToSymbolMap map[asset.Pair]types.Symbol
+	config          json.RawMessage
+}
+
+// sourceWeightConfig is the subset of a source's configuration
+// which is relevant to the AggregatePriceProvider.
+type sourceWeightConfig struct {
+	Weight *float64 `json:"weight"`
 }
 
 // NewAggregatePriceProvider instantiates a new AggregatePriceProvider instance
-// given multiple PriceProvider.
+// given multiple PriceProvider. Prices are consolidated using the default
+// aggregation config, unless a config specific to the pair is provided.
+// Panics on invalid aggregation configs.
 func NewAggregatePriceProvider(
 	sourcesToPairSymbolMap map[string]map[asset.Pair]types.Symbol,
 	sourceConfigMap map[string]json.RawMessage,
+	aggregationConfig aggregation.Config,
+	pairAggregationConfigMap map[asset.Pair]aggregation.Config,
 	logger zerolog.Logger,
-) types.PriceProvider {
-	providers := make(map[int]types.PriceProvider, len(sourcesToPairSymbolMap))
-	i := 0
+) *AggregatePriceProvider {
+	a := &AggregatePriceProvider{
+		logger:      logger.With().Str("component", "aggregate-price-provider").Logger(),
+		sources:     map[string]runningSource{},
+		newProvider: NewPriceProvider,
+	}
+	if err := a.Reload(sourcesToPairSymbolMap, sourceConfigMap, aggregationConfig, pairAggregationConfigMap); err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Reload applies new sources and aggregation settings while prices keep being served.
+// Sources which were removed are closed, new sources are started, and sources whose
+// symbols or config changed are restarted, the others keep running untouched.
+// An invalid aggregation config is returned as an error, leaving the current settings in place.
+// Reload is a no-op once the AggregatePriceProvider is closed.
+func (a *AggregatePriceProvider) Reload(
+	sourcesToPairSymbolMap map[string]map[asset.Pair]types.Symbol,
+	sourceConfigMap map[string]json.RawMessage,
+	aggregationConfig aggregation.Config,
+	pairAggregationConfigMap map[asset.Pair]aggregation.Config,
+) error {
+	a.reloadMutex.Lock()
+	defer a.reloadMutex.Unlock()
+	if a.closed {
+		return nil
+	}
+
+	sourceWeights := make(map[string]float64)
+	for sourceName := range sourcesToPairSymbolMap {
+		if weight, ok := getSourceWeight(sourceConfigMap[sourceName]); ok {
+			sourceWeights[sourceName] = weight
+		}
+	}
+
+	aggregator, err := aggregation.New(aggregationConfig, sourceWeights)
+	if err != nil {
+		return err
+	}
+	pairAggregators := make(map[asset.Pair]aggregation.Aggregator, len(pairAggregationConfigMap))
+	for pair, config := range pairAggregationConfigMap {
+		pairAggregators[pair], err = aggregation.New(config, sourceWeights)
+		if err != nil {
+			return err
+		}
+	}
+
+	sources := make(map[string]runningSource, len(sourcesToPairSymbolMap))
+	var stale []types.PriceProvider
 	for sourceName, pairToSymbolMap := range sourcesToPairSymbolMap {
-		providers[i] = NewPriceProvider(sourceName, pairToSymbolMap, sourceConfigMap[sourceName], logger)
-		i++
+		config := sourceConfigMap[sourceName]
+		running, ok := a.sources[sourceName]
+		if ok && reflect.DeepEqual(running.pairToSymbolMap, pairToSymbolMap) && bytes.Equal(running.config, config) {
+			sources[sourceName] = running
+			continue
+		}
+		if ok {
+			a.logger.Info().Str("source", sourceName).Msg("restarting source with new settings")
+			stale = append(stale, running.provider)
+		} else if len(a.sources) > 0 {
+			a.logger.Info().Str("source", sourceName).Msg("starting new source")
+		}
+		sources[sourceName] = runningSource{
+			provider:        a.newProvider(sourceName, pairToSymbolMap, config, a.logger),
+			pairToSymbolMap: pairToSymbolMap,
+			config:          config,
+		}
+	}
+	for sourceName, running := range a.sources {
+		if _, ok := sources[sourceName]; !ok {
+			a.logger.Info().Str("source", sourceName).Msg("stopping removed source")
+			stale = append(stale, running.provider)
+		}
 	}
 
-	return AggregatePriceProvider{
-		logger:    logger.With().Str("component", "aggregate-price-provider").Logger(),
-		providers: providers,
+	providers := make(map[int]types.PriceProvider, len(sources))
+	for _, running := range sources {
+		providers[len(providers)] = running.provider
 	}
+
+	a.mu.Lock()
+	a.sources = sources
+	a.providers = providers
+	a.aggregator = aggregator
+	a.pairAggregators = pairAggregators
+	a.mu.Unlock()
+
+	// stale providers are closed once no longer queried
+	for _, p := range stale {
+		p.Close()
+	}
+	return nil
+}
+
+// getSourceWeight extracts the static weight from the source config, if any.
+func getSourceWeight(sourceConfig json.RawMessage) (float64, bool) {
+	if len(sourceConfig) == 0 {
+		return 0, false
+	}
+	c := new(sourceWeightConfig)
+	if err := json.Unmarshal(sourceConfig, c); err != nil || c.Weight == nil || *c.Weight < 0 {
+		return 0, false
+	}
+	return *c.Weight, true
 }
 
 var aggregatePriceProvider = promauto.NewCounterVec(prometheus.CounterOpts{
@@ -51,7 +171,10 @@ var aggregatePriceProvider = promauto.NewCounterVec(prometheus.CounterOpts{
 // GetPrice fetches the first available and correct price from the wrapped PriceProviders.
 // Iteration is exhaustive and random.
 // If no correct PriceResponse is found, then an invalid PriceResponse is returned.
-func (a AggregatePriceProvider) GetPrice(pair asset.Pair) types.Price {
+func (a *AggregatePriceProvider) GetPrice(pair asset.Pair) types.Price {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	// iterate randomly, if we find a valid price, we return it
 	// otherwise we go onto the next PriceProvider to ask for prices.
 	var allPrices []types.Price
@@ -75,82 +198,46 @@ func (a AggregatePriceProvider) GetPrice(pair asset.Pair) types.Price {
 	return types.Price{
 		SourceName: "missing",
 		Pair:       pair,
-		Price:      0,
+		Price:      sdkmath.LegacyZeroDec(),
 		Valid:      false,
 	}
 }
 
-func (a AggregatePriceProvider) Close() {
+func (a *AggregatePriceProvider) Close() {
+	a.reloadMutex.Lock()
+	defer a.reloadMutex.Unlock()
+	a.closed = true
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	for _, p := range a.providers {
 		p.Close()
 	}
 }
 
-// computeConsolidatedPrice computes the consolidated price from the given map of prices.
-// it removes outliers and computes the median of the remaining prices.
-func (a AggregatePriceProvider) computeConsolidatedPrice(prices []types.Price, pair asset.Pair) types.Price {
-	if len(prices) == 0 {
-		return types.Price{Price: -1, Pair: pair, SourceName: "missing", Valid: false}
-	}
-	if len(prices) == 1 {
-		return prices[0]
-	}
-	if len(prices) == 2 {
-		avg := (prices[0].Price + prices[1].Price) / 2
-		return types.Price{Price: avg, Pair: pair, SourceName: "consolidated", Valid: true}
+// computeConsolidatedPrice computes the consolidated price from the given map of prices
+// using the aggregator configured for the pair.
+func (a *AggregatePriceProvider) computeConsolidatedPrice(prices []types.Price, pair asset.Pair) types.Price {
+	aggregator, ok := a.pairAggregators[pair]
+	if !ok {
+		aggregator = a.aggregator
 	}
 
-	floatPrices := make([]float64, len(prices))
-	for i, p := range prices {
-		floatPrices[i] = p.Price
+	result := aggregator.Aggregate(prices)
+	if result.Err != nil {
+		a.logger.Warn().Err(result.Err).Str("pair", pair.String()).Msg("prices rejected by aggregation")
 	}
-
-	// remove outliers, then take median
-	cleaned := a.removeOutliers(floatPrices, pair)
-	if len(cleaned) == 0 {
-		return types.Price{Price: -1, Pair: pair, SourceName: "missing", Valid: false}
-	}
-	return types.Price{Price: a.median(cleaned), Pair: pair, SourceName: "consolidated", Valid: true}
-}
-
-// removeOutliers removes outliers from the given prices slice.
-func (a AggregatePriceProvider) removeOutliers(prices []float64, pair asset.Pair) []float64 {
-	mean, stddev := a.meanAndStdDev(prices)
-	var filtered []float64
-	for _, p := range prices {
-		if math.Abs(p-mean) <= 1*stddev { // 2* would be too loose
-			filtered = append(filtered, p)
-			continue
-		}
-
-		// log outliers
-		a.logger.Warn().Str("pair", pair.String()).Float64("price", p).Float64("mean", mean).Float64("stddev", stddev).Msg("outlier price")
-	}
-	return filtered
-}
-
-// median returns the median of the given prices slice.
-func (a AggregatePriceProvider) median(prices []float64) float64 {
-	sort.Float64s(prices)
-	mid := len(prices) / 2
-	if len(prices)%2 == 1 {
-		return prices[mid]
+	for _, p := range result.Dropped {
+		a.logger.Warn().Str("pair", pair.String()).Str("source", p.SourceName).Stringer("price", p.Price).Msg("price dropped by aggregation")
 	}
-	return (prices[mid-1] + prices[mid]) / 2
-}
 
-// meanAndStdDev returns the mean and standard deviation of the given prices slice.
-func (a AggregatePriceProvider) meanAndStdDev(prices []float64) (float64, float64) {
-	var sum float64
-	for _, p := range prices {
-		sum += p
-	}
-	mean := sum / float64(len(prices))
-	var variance float64
-	for _, p := range prices {
-		diff := p - mean
-		variance += diff * diff
+	switch len(result.Kept) {
+	case 0:
+		return types.Price{Price: sdkmath.LegacyZeroDec(), Pair: pair, SourceName: "missing", Valid: false}
+	case 1:
+		return types.Price{Price: result.Price, Volume: result.Volume, Pair: pair, SourceName: result.Kept[0].SourceName, Valid: true}
+	default:
+		return types.Price{Price: result.Price, Volume: result.Volume, Pair: pair, SourceName: "consolidated", Valid: true}
 	}
-	variance /= float64(len(prices) - 1)
-	return mean, math.Sqrt(variance)
 }
@@ -1,11 +1,14 @@
 package priceprovider
 
 import (
+	"encoding/json"
 	"testing"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/vsc-blockchain/core/x/common/asset"
+	"github.com/vsc-blockchain/pricefeeder/feeder/priceprovider/aggregation"
 	"github.com/vsc-blockchain/pricefeeder/types"
 )
 
@@ -18,15 +21,16 @@ func (m mockProvider) GetPrice(pair asset.Pair) types.Price {
 	if price, ok := m.prices[pair]; ok {
 		return price
 	}
-	return types.Price{Price: -1, Valid: false}
+	return types.Price{Price: sdkmath.LegacyMustNewDecFromStr("-1"), Valid: false}
 }
 func (m mockProvider) Close() {}
 
 // TestAggregateNoValidPrices ensures we return an invalid price if no providers return valid data.
 func TestAggregateNoValidPrices(t *testing.T) {
 	agg := AggregatePriceProvider{
-		logger:    zerolog.Nop(),
-		providers: map[int]types.PriceProvider{0: mockProvider{prices: map[asset.Pair]types.Price{}}},
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
+		providers:  map[int]types.PriceProvider{0: mockProvider{prices: map[asset.Pair]types.Price{}}},
 	}
 	price := agg.GetPrice(asset.MustNewPair("BTC:USD"))
 	require.False(t, price.Valid)
@@ -36,74 +40,78 @@ func TestAggregateNoValidPrices(t *testing.T) {
 func TestAggregateSinglePrice(t *testing.T) {
 	btcPair := asset.MustNewPair("BTC:USD")
 	agg := AggregatePriceProvider{
-		logger: zerolog.Nop(),
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
 		providers: map[int]types.PriceProvider{
 			0: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 1000.0, Valid: true},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Valid: true},
 			}},
 			1: mockProvider{prices: map[asset.Pair]types.Price{}},
 		},
 	}
 	price := agg.GetPrice(btcPair)
 	require.True(t, price.Valid)
-	require.Equal(t, 1000.0, price.Price)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1000.0").Equal(price.Price), "got %s", price.Price)
 }
 
 // TestAggregateTwoPrices ensures we average two valid prices.
 func TestAggregateTwoPrices(t *testing.T) {
 	btcPair := asset.MustNewPair("BTC:USD")
 	agg := AggregatePriceProvider{
-		logger: zerolog.Nop(),
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
 		providers: map[int]types.PriceProvider{
 			0: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 1000.0, Valid: true},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Valid: true},
 			}},
 			1: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 2000.0, Valid: true},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("2000.0"), Valid: true},
 			}},
 		},
 	}
 	price := agg.GetPrice(btcPair)
 	require.True(t, price.Valid)
-	require.Equal(t, 1500.0, price.Price)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1500.0").Equal(price.Price), "got %s", price.Price)
 }
 
 // TestAggregateThreePrices checks median after removing outliers.
 func TestAggregateThreePrices(t *testing.T) {
 	btcPair := asset.MustNewPair("BTC:USD")
 	agg := AggregatePriceProvider{
-		logger: zerolog.Nop(),
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
 		providers: map[int]types.PriceProvider{
 			0: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 1000.0, Valid: true, SourceName: "mock1"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Valid: true, SourceName: "mock1"},
 			}},
 			1: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 2000.0, Valid: true, SourceName: "mock2"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("2000.0"), Valid: true, SourceName: "mock2"},
 			}},
 			2: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 10000.0, Valid: true, SourceName: "mock3"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("10000.0"), Valid: true, SourceName: "mock3"},
 			}},
 		},
 	}
 	price := agg.GetPrice(btcPair)
 	require.True(t, price.Valid)
 	// Outlier (100000) removed, median of {1000, 2000} is 1500
-	require.Equal(t, 1500.0, price.Price)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1500.0").Equal(price.Price), "got %s", price.Price)
 
 	agg = AggregatePriceProvider{
-		logger: zerolog.Nop(),
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
 		providers: map[int]types.PriceProvider{
 			0: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 1000.0, Valid: true, SourceName: "mock1"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Valid: true, SourceName: "mock1"},
 			}},
 			1: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 2000.0, Valid: true, SourceName: "mock2"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("2000.0"), Valid: true, SourceName: "mock2"},
 			}},
 			2: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 10000.0, Valid: true, SourceName: "mock3"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("10000.0"), Valid: true, SourceName: "mock3"},
 			}},
 			3: mockProvider{prices: map[asset.Pair]types.Price{
-				btcPair: {Price: 2000.0, Valid: true, SourceName: "mock4"},
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("2000.0"), Valid: true, SourceName: "mock4"},
 			}},
 		},
 	}
@@ -111,5 +119,221 @@ func TestAggregateThreePrices(t *testing.T) {
 	price = agg.GetPrice(btcPair)
 	require.True(t, price.Valid)
 	// Outlier (100000) removed, median of {1000, 2000, 2000} is 2000
-	require.Equal(t, 2000.0, price.Price)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("2000.0").Equal(price.Price), "got %s", price.Price)
+}
+
+// TestAggregateVolumeWeightedMedian checks that prices are weighted by volume when every source reports it.
+func TestAggregateVolumeWeightedMedian(t *testing.T) {
+	btcPair := asset.MustNewPair("BTC:USD")
+	agg := AggregatePriceProvider{
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
+		providers: map[int]types.PriceProvider{
+			0: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Volume: 10, Valid: true, SourceName: "thin1"},
+			}},
+			1: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1010.0"), Volume: 1_000, Valid: true, SourceName: "deep"},
+			}},
+			2: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1020.0"), Volume: 10, Valid: true, SourceName: "thin2"},
+			}},
+			3: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1015.0"), Volume: 10, Valid: true, SourceName: "thin3"},
+			}},
+		},
+	}
+	price := agg.GetPrice(btcPair)
+	require.True(t, price.Valid)
+	// 1000 and 1020 are removed as outliers, the unweighted median
+	// of the remaining prices would be 1012.5
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1010.0").Equal(price.Price), "got %s", price.Price)
+	require.Equal(t, 1010.0, price.Volume)
+
+	// two sources are averaged by volume
+	agg = AggregatePriceProvider{
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(nil),
+		providers: map[int]types.PriceProvider{
+			0: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Volume: 100, Valid: true, SourceName: "thin"},
+			}},
+			1: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("2000.0"), Volume: 300, Valid: true, SourceName: "deep"},
+			}},
+		},
+	}
+	price = agg.GetPrice(btcPair)
+	require.True(t, price.Valid)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1750.0").Equal(price.Price), "got %s", price.Price)
+}
+
+// TestAggregateStaticWeights checks that static weights are used when a source does not report volume.
+func TestAggregateStaticWeights(t *testing.T) {
+	btcPair := asset.MustNewPair("BTC:USD")
+	agg := AggregatePriceProvider{
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewMedian(map[string]float64{"mock1": 3}),
+		providers: map[int]types.PriceProvider{
+			0: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Volume: 10, Valid: true, SourceName: "mock1"},
+			}},
+			1: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1010.0"), Valid: true, SourceName: "mock2"},
+			}},
+			2: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1020.0"), Volume: 10_000, Valid: true, SourceName: "mock3"},
+			}},
+		},
+	}
+	price := agg.GetPrice(btcPair)
+	require.True(t, price.Valid)
+	// volumes are ignored since mock2 has none, mock1 weighs 3 and the others 1
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1000.0").Equal(price.Price), "got %s", price.Price)
+}
+
+func TestGetSourceWeight(t *testing.T) {
+	weight, ok := getSourceWeight(json.RawMessage(`{"api_key": "0123", "weight": 0.5}`))
+	require.True(t, ok)
+	require.Equal(t, 0.5, weight)
+
+	_, ok = getSourceWeight(json.RawMessage(`{"api_key": "0123"}`))
+	require.False(t, ok)
+
+	_, ok = getSourceWeight(nil)
+	require.False(t, ok)
+}
+
+// TestAggregatePairStrategy checks that the aggregator configured for a pair is used.
+func TestAggregatePairStrategy(t *testing.T) {
+	btcPair := asset.MustNewPair("BTC:USD")
+	ethPair := asset.MustNewPair("ETH:USD")
+	providers := map[int]types.PriceProvider{
+		0: mockProvider{prices: map[asset.Pair]types.Price{
+			btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Valid: true, SourceName: "mock1"},
+			ethPair: {Price: sdkmath.LegacyMustNewDecFromStr("100.0"), Valid: true, SourceName: "mock1"},
+		}},
+		1: mockProvider{prices: map[asset.Pair]types.Price{
+			btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("2000.0"), Valid: true, SourceName: "mock2"},
+			ethPair: {Price: sdkmath.LegacyMustNewDecFromStr("200.0"), Valid: true, SourceName: "mock2"},
+		}},
+	}
+	agg := AggregatePriceProvider{
+		logger:          zerolog.Nop(),
+		aggregator:      aggregation.NewMedian(nil),
+		pairAggregators: map[asset.Pair]aggregation.Aggregator{btcPair: aggregation.NewPriority([]string{"mock2", "mock1"})},
+		providers:       providers,
+	}
+
+	price := agg.GetPrice(btcPair)
+	require.True(t, price.Valid)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("2000.0").Equal(price.Price), "got %s", price.Price)
+	require.Equal(t, "mock2", price.SourceName)
+
+	price = agg.GetPrice(ethPair)
+	require.True(t, price.Valid)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("150.0").Equal(price.Price), "got %s", price.Price)
+	require.Equal(t, "consolidated", price.SourceName)
+}
+
+// TestAggregateQuorum ensures a single unconfirmed price is not voted when a quorum is required.
+func TestAggregateQuorum(t *testing.T) {
+	btcPair := asset.MustNewPair("BTC:USD")
+	agg := AggregatePriceProvider{
+		logger:     zerolog.Nop(),
+		aggregator: aggregation.NewQuorum(aggregation.NewMedian(nil), 2, 0),
+		providers: map[int]types.PriceProvider{
+			0: mockProvider{prices: map[asset.Pair]types.Price{
+				btcPair: {Price: sdkmath.LegacyMustNewDecFromStr("1000.0"), Valid: true, SourceName: "mock1"},
+			}},
+			1: mockProvider{prices: map[asset.Pair]types.Price{}},
+		},
+	}
+	price := agg.GetPrice(btcPair)
+	require.False(t, price.Valid)
+	require.Equal(t, btcPair, price.Pair)
+}
+
+// closeTrackingProvider is a mockProvider recording when it is closed.
+type closeTrackingProvider struct {
+	mockProvider
+	closed *bool
+}
+
+func (c closeTrackingProvider) Close() { *c.closed = true }
+
+func TestAggregateReload(t *testing.T) {
+	btcPair := asset.MustNewPair("BTC:USD")
+	started := map[string]int{}
+	closed := map[string]*bool{}
+	agg := &AggregatePriceProvider{
+		logger:  zerolog.Nop(),
+		sources: map[string]runningSource{},
+		newProvider: func(sourceName string, pairToSymbolMap map[asset.Pair]types.Symbol, config json.RawMessage, logger zerolog.Logger) types.PriceProvider {
+			started[sourceName]++
+			closed[sourceName] = new(bool)
+			return closeTrackingProvider{
+				mockProvider: mockProvider{prices: map[asset.Pair]types.Price{
+					btcPair: {Price: sdkmath.LegacyNewDec(1000 * int64(len(sourceName))), Valid: true, SourceName: sourceName},
+				}},
+				closed: closed[sourceName],
+			}
+		},
+	}
+
+	symbols := map[asset.Pair]types.Symbol{btcPair: "BTCUSD"}
+	require.NoError(t, agg.Reload(
+		map[string]map[asset.Pair]types.Symbol{"a": symbols, "bb": symbols},
+		map[string]json.RawMessage{},
+		aggregation.Config{Strategy: aggregation.Median},
+		nil,
+	))
+	require.Equal(t, map[string]int{"a": 1, "bb": 1}, started)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("1500.0").Equal(agg.GetPrice(btcPair).Price), "got %s", agg.GetPrice(btcPair).Price)
+
+	// "a" is untouched, "bb" is removed, "ccc" is new
+	closedBB := closed["bb"]
+	require.NoError(t, agg.Reload(
+		map[string]map[asset.Pair]types.Symbol{"a": symbols, "ccc": symbols},
+		map[string]json.RawMessage{},
+		aggregation.Config{Strategy: aggregation.Median},
+		nil,
+	))
+	require.Equal(t, map[string]int{"a": 1, "bb": 1, "ccc": 1}, started)
+	require.True(t, *closedBB)
+	require.False(t, *closed["a"])
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("2000.0").Equal(agg.GetPrice(btcPair).Price), "got %s", agg.GetPrice(btcPair).Price)
+
+	// a changed config restarts the source
+	closedA := closed["a"]
+	require.NoError(t, agg.Reload(
+		map[string]map[asset.Pair]types.Symbol{"a": symbols, "ccc": symbols},
+		map[string]json.RawMessage{"a": json.RawMessage(`{"weight": 2}`)},
+		aggregation.Config{Strategy: aggregation.Median},
+		nil,
+	))
+	require.Equal(t, 2, started["a"])
+	require.True(t, *closedA)
+	require.Equal(t, 1, started["ccc"])
+
+	// an invalid aggregation config keeps the current settings
+	require.Error(t, agg.Reload(
+		map[string]map[asset.Pair]types.Symbol{},
+		map[string]json.RawMessage{},
+		aggregation.Config{Strategy: "unknown"},
+		nil,
+	))
+	require.False(t, *closed["a"])
+	require.True(t, agg.GetPrice(btcPair).Valid)
+
+	// a reload racing the shutdown starts no source which would never be closed
+	agg.Close()
+	require.NoError(t, agg.Reload(
+		map[string]map[asset.Pair]types.Symbol{"a": symbols, "dddd": symbols},
+		map[string]json.RawMessage{},
+		aggregation.Config{Strategy: aggregation.Median},
+		nil,
+	))
+	require.Zero(t, started["dddd"])
+	require.True(t, *closed["a"])
 }
@@ -0,0 +1,302 @@
+package aggregation
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+const (
+	Median      = "median"
+	TrimmedMean = "trimmed_mean"
+	MAD         = "mad"
+	VWAP        = "vwap"
+	Priority    = "priority"
+)
+
+// Aggregator consolidates the prices provided by multiple
+// sources for the same asset.Pair into a single price.
+// The consolidated price and the statistics used to detect outliers are
+// computed from the exact prices of the sources, only the weights are float64.
+type Aggregator interface {
+	// Aggregate returns the consolidated price given the valid
+	// prices of every source, and reports which of them were
+	// kept and which were dropped in the process.
+	Aggregate(prices []types.Price) Result
+}
+
+// Result is the outcome of an aggregation.
+type Result struct {
+	// Price is the consolidated price, meaningful only if Kept is not empty.
+	Price sdkmath.LegacyDec
+	// Volume is the total volume of the kept prices.
+	Volume float64
+	// Kept are the prices which contributed to the consolidated price.
+	Kept []types.Price
+	// Dropped are the prices which were discarded.
+	Dropped []types.Price
+	// Err reports why the prices were rejected as a whole, if they were.
+	Err error
+}
+
+// Valid reports whether at least one price contributed to the result.
+func (r Result) Valid() bool {
+	return len(r.Kept) > 0
+}
+
+func newResult(price sdkmath.LegacyDec, kept, dropped []types.Price) Result {
+	var volume float64
+	for _, p := range kept {
+		volume += p.Volume
+	}
+	return Result{
+		Price:   price,
+		Volume:  volume,
+		Kept:    kept,
+		Dropped: dropped,
+	}
+}
+
+// Config defines the aggregation strategy used for a pair.
+type Config struct {
+	// Strategy is the name of the strategy, defaults to Median.
+	Strategy string `json:"strategy"`
+	// Trim is the fraction of prices removed from each end
+	// by the TrimmedMean strategy, defaults to DefaultTrim.
+	Trim float64 `json:"trim,omitempty"`
+	// Threshold is the maximum distance from the median, in scaled
+	// median absolute deviations, tolerated by the MAD strategy.
+	// Defaults to DefaultMADThreshold.
+	Threshold float64 `json:"threshold,omitempty"`
+	// Sources is the ordered list of sources used by the Priority strategy.
+	Sources []string `json:"sources,omitempty"`
+	// MinSources is the minimum number of sources whose prices must be
+	// kept by the strategy for the result to be valid.
+	MinSources int `json:"min_sources,omitempty"`
+	// MaxSpread is the maximum spread between the kept prices, relative
+	// to the consolidated price, for the result to be valid. Zero disables it.
+	MaxSpread float64 `json:"max_spread,omitempty"`
+}
+
+// New returns the Aggregator defined by the given Config. The source weights
+// are used by the weighted strategies when sources do not report volumes.
+func New(c Config, sourceWeights map[string]float64) (Aggregator, error) {
+	aggregator, err := newStrategy(c, sourceWeights)
+	if err != nil {
+		return nil, err
+	}
+	if c.MinSources < 0 {
+		return nil, fmt.Errorf("min_sources must be positive, got %d", c.MinSources)
+	}
+	if c.MaxSpread < 0 {
+		return nil, fmt.Errorf("max_spread must be positive, got %g", c.MaxSpread)
+	}
+	if c.MinSources > 1 || c.MaxSpread > 0 {
+		return NewQuorum(aggregator, c.MinSources, c.MaxSpread), nil
+	}
+	return aggregator, nil
+}
+
+// newStrategy returns the Aggregator implementing the strategy of the given Config.
+func newStrategy(c Config, sourceWeights map[string]float64) (Aggregator, error) {
+	switch c.Strategy {
+	case "", Median:
+		return NewMedian(sourceWeights), nil
+	case TrimmedMean:
+		trim := c.Trim
+		if trim == 0 {
+			trim = DefaultTrim
+		}
+		if trim < 0 || trim >= 0.5 {
+			return nil, fmt.Errorf("%s: trim must be between 0 and 0.5, got %g", TrimmedMean, c.Trim)
+		}
+		return NewTrimmedMean(trim, sourceWeights), nil
+	case MAD:
+		threshold := c.Threshold
+		if threshold == 0 {
+			threshold = DefaultMADThreshold
+		}
+		if threshold < 0 {
+			return nil, fmt.Errorf("%s: threshold must be positive, got %g", MAD, c.Threshold)
+		}
+		return NewMAD(threshold, sourceWeights), nil
+	case VWAP:
+		return NewVWAP(), nil
+	case Priority:
+		if len(c.Sources) == 0 {
+			return nil, fmt.Errorf("%s: no sources configured", Priority)
+		}
+		return NewPriority(c.Sources), nil
+	default:
+		return nil, fmt.Errorf("unknown aggregation strategy: %s", c.Strategy)
+	}
+}
+
+// Weights returns the aggregation weight of each of the given prices.
+// Prices are weighted by their 24h quote volume if every source reported one,
+// otherwise the static source weights are used, defaulting to 1.
+// Volumes and static weights are never mixed as they are not comparable.
+func Weights(prices []types.Price, sourceWeights map[string]float64) []float64 {
+	useVolume := true
+	for _, p := range prices {
+		if p.Volume <= 0 {
+			useVolume = false
+			break
+		}
+	}
+
+	weights := make([]float64, len(prices))
+	for i, p := range prices {
+		if useVolume {
+			weights[i] = p.Volume
+			continue
+		}
+		weights[i] = 1
+		if weight, ok := sourceWeights[p.SourceName]; ok {
+			weights[i] = weight
+		}
+	}
+	return weights
+}
+
+// sortByPrice returns a copy of the given prices sorted in ascending order.
+func sortByPrice(prices []types.Price) []types.Price {
+	sorted := make([]types.Price, len(prices))
+	copy(sorted, prices)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Price.LT(sorted[j].Price) })
+	return sorted
+}
+
+// medianPrice returns the median of the given prices.
+func medianPrice(prices []types.Price) sdkmath.LegacyDec {
+	values := make([]sdkmath.LegacyDec, len(prices))
+	for i, p := range prices {
+		values[i] = p.Price
+	}
+	return median(values)
+}
+
+// median returns the median of the given decimals.
+func median(values []sdkmath.LegacyDec) sdkmath.LegacyDec {
+	sorted := make([]sdkmath.LegacyDec, len(values))
+	copy(sorted, values)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LT(sorted[j]) })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return midpoint(sorted[mid-1], sorted[mid])
+}
+
+// midpoint returns the average of the two given prices.
+func midpoint(a, b sdkmath.LegacyDec) sdkmath.LegacyDec {
+	return a.Add(b).QuoInt64(2)
+}
+
+// weightedMean returns the weighted mean of the given prices,
+// or their median if all the weights are zero.
+func weightedMean(prices []types.Price, weights []float64) sdkmath.LegacyDec {
+	decWeights := toDecWeights(weights)
+	sum, totalWeight := sdkmath.LegacyZeroDec(), sdkmath.LegacyZeroDec()
+	for i, p := range prices {
+		sum = sum.Add(p.Price.Mul(decWeights[i]))
+		totalWeight = totalWeight.Add(decWeights[i])
+	}
+	if totalWeight.IsZero() {
+		return medianPrice(prices)
+	}
+	return sum.Quo(totalWeight)
+}
+
+// toDecWeights returns the weights as decimals, see toDec.
+func toDecWeights(weights []float64) []sdkmath.LegacyDec {
+	decWeights := make([]sdkmath.LegacyDec, len(weights))
+	for i, w := range weights {
+		decWeights[i] = toDec(w)
+	}
+	return decWeights
+}
+
+// toDec returns the given float64 as a decimal, parsed from its shortest
+// representation so that round volumes, weights and thresholds are exact.
+// Values which are not positive or do not fit a LegacyDec are zero.
+func toDec(f float64) sdkmath.LegacyDec {
+	if f <= 0 {
+		return sdkmath.LegacyZeroDec()
+	}
+	d, err := types.ParsePrice(strconv.FormatFloat(f, 'g', -1, 64))
+	if err != nil {
+		return sdkmath.LegacyZeroDec()
+	}
+	return d
+}
+
+// weightedMedian returns the weighted median of the given prices,
+// or their median if all the weights are zero.
+// With equal weights it is equivalent to the median.
+func weightedMedian(prices []types.Price, weights []float64) sdkmath.LegacyDec {
+	type weightedPrice struct {
+		price  sdkmath.LegacyDec
+		weight float64
+	}
+	sorted := make([]weightedPrice, len(prices))
+	var totalWeight float64
+	for i, p := range prices {
+		sorted[i] = weightedPrice{price: p.Price, weight: weights[i]}
+		totalWeight += weights[i]
+	}
+	if totalWeight == 0 {
+		return medianPrice(prices)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].price.LT(sorted[j].price) })
+
+	var cumulative float64
+	for i, p := range sorted {
+		cumulative += p.weight
+		if cumulative*2 > totalWeight {
+			return p.price
+		}
+		if cumulative*2 == totalWeight {
+			// the median falls exactly between this price and the next weighted one
+			for j := i + 1; j < len(sorted); j++ {
+				if sorted[j].weight > 0 {
+					return midpoint(p.price, sorted[j].price)
+				}
+			}
+			return p.price
+		}
+	}
+	return sorted[len(sorted)-1].price
+}
+
+// withinStdDev reports, for each of the given prices, whether its distance from
+// their mean is at most their sample standard deviation. With n prices summing to sum,
+// this holds when (n-1)*(n*price - sum)^2 <= the sum of (n*p - sum)^2 over all prices,
+// which is compared on the integer representation of the decimals to be exact.
+func withinStdDev(prices []types.Price) []bool {
+	n := big.NewInt(int64(len(prices)))
+	sum := new(big.Int)
+	for _, p := range prices {
+		sum.Add(sum, p.Price.BigInt())
+	}
+
+	squares := make([]*big.Int, len(prices))
+	variance := new(big.Int)
+	for i, p := range prices {
+		diff := new(big.Int).Mul(n, p.Price.BigInt())
+		diff.Sub(diff, sum)
+		squares[i] = diff.Mul(diff, diff)
+		variance.Add(variance, squares[i])
+	}
+
+	within := make([]bool, len(prices))
+	for i, square := range squares {
+		scaled := new(big.Int).Mul(square, big.NewInt(int64(len(prices)-1)))
+		within[i] = scaled.Cmp(variance) <= 0
+	}
+	return within
+}
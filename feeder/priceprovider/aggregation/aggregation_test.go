@@ -0,0 +1,85 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// sources returns the source names of the given prices.
+func sources(prices []types.Price) []string {
+	names := make([]string, len(prices))
+	for i, p := range prices {
+		names[i] = p.SourceName
+	}
+	return names
+}
+
+func TestNew(t *testing.T) {
+	t.Run("defaults to median", func(t *testing.T) {
+		agg, err := New(Config{}, nil)
+		require.NoError(t, err)
+		require.IsType(t, medianAggregator{}, agg)
+	})
+
+	t.Run("known strategies", func(t *testing.T) {
+		for _, c := range []Config{
+			{Strategy: Median},
+			{Strategy: TrimmedMean, Trim: 0.1},
+			{Strategy: MAD},
+			{Strategy: VWAP},
+			{Strategy: Priority, Sources: []string{"okex"}},
+		} {
+			_, err := New(c, nil)
+			require.NoError(t, err, c.Strategy)
+		}
+	})
+
+	t.Run("invalid configs", func(t *testing.T) {
+		for _, c := range []Config{
+			{Strategy: "unknown"},
+			{Strategy: TrimmedMean, Trim: 0.5},
+			{Strategy: MAD, Threshold: -1},
+			{Strategy: Priority},
+		} {
+			_, err := New(c, nil)
+			require.Error(t, err, c.Strategy)
+		}
+	})
+}
+
+func TestWeights(t *testing.T) {
+	t.Run("volumes", func(t *testing.T) {
+		prices := []types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), Volume: 10, SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), Volume: 20, SourceName: "b"},
+		}
+		require.Equal(t, []float64{10, 20}, Weights(prices, map[string]float64{"a": 3}))
+	})
+
+	t.Run("static weights when a volume is missing", func(t *testing.T) {
+		prices := []types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), Volume: 10, SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "b"},
+		}
+		require.Equal(t, []float64{3, 1}, Weights(prices, map[string]float64{"a": 3}))
+	})
+}
+
+func TestWeightedMedian(t *testing.T) {
+	prices := []types.Price{{Price: sdkmath.LegacyMustNewDecFromStr("4")}, {Price: sdkmath.LegacyMustNewDecFromStr("1")}, {Price: sdkmath.LegacyMustNewDecFromStr("3")}, {Price: sdkmath.LegacyMustNewDecFromStr("2")}}
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("2.5").Equal(weightedMedian(prices, []float64{1, 1, 1, 1})), "got %s", weightedMedian(prices, []float64{1, 1, 1, 1}))
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("4.0").Equal(weightedMedian(prices, []float64{5, 1, 1, 1})), "got %s", weightedMedian(prices, []float64{5, 1, 1, 1}))
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("2.5").Equal(weightedMedian(prices, []float64{0, 0, 0, 0})), "got %s", weightedMedian(prices, []float64{0, 0, 0, 0}))
+}
+
+func TestWeightedMean(t *testing.T) {
+	prices := []types.Price{{Price: sdkmath.LegacyMustNewDecFromStr("0.000000001234567891")}, {Price: sdkmath.LegacyMustNewDecFromStr("0.000000001234567893")}}
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("0.000000001234567892").Equal(weightedMean(prices, []float64{1, 1})), "got %s", weightedMean(prices, []float64{1, 1}))
+
+	prices = []types.Price{{Price: sdkmath.LegacyMustNewDecFromStr("98765.432109876543210987")}, {Price: sdkmath.LegacyMustNewDecFromStr("98765.432109876543210989")}}
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("98765.432109876543210988").Equal(weightedMean(prices, []float64{1_000_000, 1_000_000})), "got %s", weightedMean(prices, []float64{1_000_000, 1_000_000}))
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("98765.432109876543210987").Equal(weightedMean(prices, []float64{1, 0})), "got %s", weightedMean(prices, []float64{1, 0}))
+}
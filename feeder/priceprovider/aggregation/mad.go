@@ -0,0 +1,60 @@
+package aggregation
+
+import (
+	sdkmath "cosmossdk.io/math"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+const (
+	// DefaultMADThreshold is the default number of scaled median
+	// absolute deviations a price can be away from the median.
+	DefaultMADThreshold = 3.0
+	// madScale makes the median absolute deviation a consistent
+	// estimator of the standard deviation for normal distributions.
+	madScale = 1.4826
+	// madMinRelDeviation is the distance from the median, relative to the median, which is
+	// always tolerated. When most sources agree exactly the median absolute deviation is zero,
+	// and any price a tick away would be dropped otherwise.
+	madMinRelDeviation = 0.001
+)
+
+var _ Aggregator = (*madAggregator)(nil)
+
+// madAggregator removes the prices whose distance from the median is larger than
+// threshold scaled median absolute deviations, but at least madMinRelDeviation of the median,
+// then takes the weighted median of the remaining ones. Unlike the standard deviation,
+// the median absolute deviation is not skewed by the outliers themselves.
+type madAggregator struct {
+	// scale is the threshold times madScale.
+	scale         sdkmath.LegacyDec
+	sourceWeights map[string]float64
+}
+
+// NewMAD returns the MAD Aggregator.
+func NewMAD(threshold float64, sourceWeights map[string]float64) Aggregator {
+	return madAggregator{scale: toDec(threshold).Mul(toDec(madScale)), sourceWeights: sourceWeights}
+}
+
+func (m madAggregator) Aggregate(prices []types.Price) Result {
+	if len(prices) == 0 {
+		return Result{}
+	}
+
+	center := medianPrice(prices)
+	deviations := make([]sdkmath.LegacyDec, len(prices))
+	for i, p := range prices {
+		deviations[i] = p.Price.Sub(center).Abs()
+	}
+	maxDeviation := sdkmath.LegacyMaxDec(m.scale.Mul(median(deviations)), toDec(madMinRelDeviation).Mul(center))
+
+	var kept, dropped []types.Price
+	for i, p := range prices {
+		if deviations[i].LTE(maxDeviation) {
+			kept = append(kept, p)
+			continue
+		}
+		dropped = append(dropped, p)
+	}
+
+	return newResult(weightedMedian(kept, Weights(kept, m.sourceWeights)), kept, dropped)
+}
@@ -0,0 +1,48 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestMAD(t *testing.T) {
+	t.Run("drops outliers", func(t *testing.T) {
+		result := NewMAD(DefaultMADThreshold, nil).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("100"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("101"), SourceName: "b"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("99"), SourceName: "c"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("100.5"), SourceName: "d"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("150"), SourceName: "e"},
+		})
+		require.True(t, result.Valid())
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("100.25").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, []string{"a", "b", "c", "d"}, sources(result.Kept))
+		require.Equal(t, []string{"e"}, sources(result.Dropped))
+	})
+
+	t.Run("keeps agreeing prices", func(t *testing.T) {
+		result := NewMAD(DefaultMADThreshold, nil).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "b"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1.0001"), SourceName: "c"},
+		})
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1.0").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, []string{"a", "b", "c"}, sources(result.Kept))
+		require.Empty(t, result.Dropped)
+	})
+
+	t.Run("drops outliers when most prices are equal", func(t *testing.T) {
+		result := NewMAD(DefaultMADThreshold, nil).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "b"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "c"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1.1"), SourceName: "d"},
+		})
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1.0").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, []string{"a", "b", "c"}, sources(result.Kept))
+		require.Equal(t, []string{"d"}, sources(result.Dropped))
+	})
+}
@@ -0,0 +1,48 @@
+package aggregation
+
+import (
+	sdkmath "cosmossdk.io/math"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+var _ Aggregator = (*medianAggregator)(nil)
+
+// medianAggregator removes the prices further than one standard deviation
+// from the mean and takes the weighted median of the remaining ones.
+type medianAggregator struct {
+	sourceWeights map[string]float64
+}
+
+// NewMedian returns the Median Aggregator, the source weights
+// are used when sources do not report volumes.
+func NewMedian(sourceWeights map[string]float64) Aggregator {
+	return medianAggregator{sourceWeights: sourceWeights}
+}
+
+func (m medianAggregator) Aggregate(prices []types.Price) Result {
+	switch len(prices) {
+	case 0:
+		return Result{}
+	case 1:
+		return newResult(prices[0].Price, prices, nil)
+	case 2:
+		// with two prices we cannot tell which one is the outlier
+		return newResult(weightedMean(prices, Weights(prices, m.sourceWeights)), prices, nil)
+	}
+
+	// remove outliers, then take weighted median
+	within := withinStdDev(prices) // 2 standard deviations would be too loose
+	var kept, dropped []types.Price
+	for i, p := range prices {
+		if within[i] {
+			kept = append(kept, p)
+			continue
+		}
+		dropped = append(dropped, p)
+	}
+	if len(kept) == 0 {
+		return newResult(sdkmath.LegacyZeroDec(), nil, dropped)
+	}
+
+	return newResult(weightedMedian(kept, Weights(kept, m.sourceWeights)), kept, dropped)
+}
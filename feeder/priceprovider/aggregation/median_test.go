@@ -0,0 +1,36 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestMedian(t *testing.T) {
+	t.Run("no prices", func(t *testing.T) {
+		require.False(t, NewMedian(nil).Aggregate(nil).Valid())
+	})
+
+	t.Run("two prices are averaged", func(t *testing.T) {
+		result := NewMedian(nil).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1000"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("2000"), SourceName: "b"},
+		})
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1500.0").Equal(result.Price), "got %s", result.Price)
+		require.Empty(t, result.Dropped)
+	})
+
+	t.Run("outliers are dropped", func(t *testing.T) {
+		result := NewMedian(nil).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1000"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("2000"), SourceName: "b"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("10000"), SourceName: "c"},
+		})
+		require.True(t, result.Valid())
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1500.0").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, []string{"a", "b"}, sources(result.Kept))
+		require.Equal(t, []string{"c"}, sources(result.Dropped))
+	})
+}
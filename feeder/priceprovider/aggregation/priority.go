@@ -0,0 +1,36 @@
+package aggregation
+
+import (
+	sdkmath "cosmossdk.io/math"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+var _ Aggregator = (*priorityAggregator)(nil)
+
+// priorityAggregator takes the price of the first source, in order of
+// priority, which provided one. Every other price is dropped.
+type priorityAggregator struct {
+	sources []string
+}
+
+// NewPriority returns the Priority Aggregator given the
+// source names sorted by descending priority.
+func NewPriority(sources []string) Aggregator {
+	return priorityAggregator{sources: sources}
+}
+
+func (p priorityAggregator) Aggregate(prices []types.Price) Result {
+	for _, source := range p.sources {
+		for i, price := range prices {
+			if price.SourceName != source {
+				continue
+			}
+
+			var dropped []types.Price
+			dropped = append(dropped, prices[:i]...)
+			dropped = append(dropped, prices[i+1:]...)
+			return newResult(price.Price, []types.Price{price}, dropped)
+		}
+	}
+	return newResult(sdkmath.LegacyZeroDec(), nil, prices)
+}
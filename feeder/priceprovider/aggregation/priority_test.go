@@ -0,0 +1,39 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestPriority(t *testing.T) {
+	agg := NewPriority([]string{"okex", "bybit"})
+
+	t.Run("first valid source wins", func(t *testing.T) {
+		result := agg.Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "mexc"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("2"), SourceName: "bybit"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("3"), SourceName: "okex"},
+		})
+		require.True(t, result.Valid())
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("3.0").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, []string{"okex"}, sources(result.Kept))
+		require.Equal(t, []string{"mexc", "bybit"}, sources(result.Dropped))
+	})
+
+	t.Run("falls back to the next source", func(t *testing.T) {
+		result := agg.Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "mexc"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("2"), SourceName: "bybit"},
+		})
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("2.0").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, []string{"mexc"}, sources(result.Dropped))
+	})
+
+	t.Run("invalid if no source is listed", func(t *testing.T) {
+		result := agg.Aggregate([]types.Price{{Price: sdkmath.LegacyMustNewDecFromStr("1"), SourceName: "mexc"}})
+		require.False(t, result.Valid())
+	})
+}
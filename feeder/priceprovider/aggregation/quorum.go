@@ -0,0 +1,67 @@
+package aggregation
+
+import (
+	"fmt"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+var _ Aggregator = (*quorumAggregator)(nil)
+
+// quorumAggregator rejects the result of the wrapped Aggregator
+// if it is not backed by enough agreeing sources.
+type quorumAggregator struct {
+	aggregator Aggregator
+	minSources int
+	maxSpread  float64
+}
+
+// NewQuorum wraps the given Aggregator, rejecting its results when less
+// than minSources prices are kept, when the consolidated price is not positive,
+// or when the spread between the kept prices, relative to the consolidated price,
+// exceeds maxSpread. A zero maxSpread disables the spread check.
+func NewQuorum(aggregator Aggregator, minSources int, maxSpread float64) Aggregator {
+	return quorumAggregator{
+		aggregator: aggregator,
+		minSources: minSources,
+		maxSpread:  maxSpread,
+	}
+}
+
+func (q quorumAggregator) Aggregate(prices []types.Price) Result {
+	result := q.aggregator.Aggregate(prices)
+	if !result.Valid() {
+		return result
+	}
+
+	if len(result.Kept) < q.minSources {
+		return reject(result, fmt.Errorf("quorum not reached: %d sources, %d required", len(result.Kept), q.minSources))
+	}
+
+	// the spread is relative to the consolidated price, which a zero price cannot be voted as anyway
+	if !result.Price.IsPositive() {
+		return reject(result, fmt.Errorf("consolidated price not positive: %s", result.Price))
+	}
+
+	if q.maxSpread > 0 {
+		low, high := result.Kept[0].Price, result.Kept[0].Price
+		for _, p := range result.Kept[1:] {
+			low = sdkmath.LegacyMinDec(low, p.Price)
+			high = sdkmath.LegacyMaxDec(high, p.Price)
+		}
+		if spread := high.Sub(low); spread.GT(toDec(q.maxSpread).Mul(result.Price)) {
+			return reject(result, fmt.Errorf("spread too high: %s, max %g", spread.Quo(result.Price), q.maxSpread))
+		}
+	}
+
+	return result
+}
+
+// reject marks every price of the result as dropped for the given reason.
+func reject(result Result, err error) Result {
+	var dropped []types.Price
+	dropped = append(dropped, result.Kept...)
+	dropped = append(dropped, result.Dropped...)
+	return Result{Dropped: dropped, Err: err}
+}
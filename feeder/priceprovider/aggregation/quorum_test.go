@@ -0,0 +1,76 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestQuorum(t *testing.T) {
+	t.Run("single source rejected", func(t *testing.T) {
+		result := NewQuorum(NewMedian(nil), 2, 0).Aggregate([]types.Price{{Price: sdkmath.LegacyMustNewDecFromStr("100"), SourceName: "a"}})
+		require.False(t, result.Valid())
+		require.ErrorContains(t, result.Err, "quorum not reached")
+		require.Equal(t, []string{"a"}, sources(result.Dropped))
+	})
+
+	t.Run("dropped outliers do not count towards the quorum", func(t *testing.T) {
+		result := NewQuorum(NewMedian(nil), 3, 0).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("1000"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("2000"), SourceName: "b"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("10000"), SourceName: "c"},
+		})
+		require.False(t, result.Valid())
+		require.Len(t, result.Dropped, 3)
+	})
+
+	t.Run("spread too high", func(t *testing.T) {
+		result := NewQuorum(NewMedian(nil), 2, 0.05).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("100"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("110"), SourceName: "b"},
+		})
+		require.False(t, result.Valid())
+		require.ErrorContains(t, result.Err, "spread too high")
+	})
+
+	t.Run("spread at the limit", func(t *testing.T) {
+		// 0.3 / 0.25 is 1.2000000000000002 in float64
+		result := NewQuorum(NewMedian(nil), 2, 1.2).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("0.1"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("0.4"), SourceName: "b"},
+		})
+		require.True(t, result.Valid())
+		require.NoError(t, result.Err)
+	})
+
+	t.Run("zero price rejected", func(t *testing.T) {
+		result := NewQuorum(NewMedian(nil), 2, 0.05).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("0"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("0"), SourceName: "b"},
+		})
+		require.False(t, result.Valid())
+		require.ErrorContains(t, result.Err, "not positive")
+		require.Len(t, result.Dropped, 2)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		result := NewQuorum(NewMedian(nil), 2, 0.05).Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("100"), SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("102"), SourceName: "b"},
+		})
+		require.True(t, result.Valid())
+		require.NoError(t, result.Err)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("101.0").Equal(result.Price), "got %s", result.Price)
+	})
+
+	t.Run("configured through New", func(t *testing.T) {
+		agg, err := New(Config{Strategy: MAD, MinSources: 2}, nil)
+		require.NoError(t, err)
+		require.IsType(t, quorumAggregator{}, agg)
+
+		_, err = New(Config{MinSources: -1}, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,38 @@
+package aggregation
+
+import (
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// DefaultTrim is the fraction of prices removed from each end by the TrimmedMean strategy.
+const DefaultTrim = 0.2
+
+var _ Aggregator = (*trimmedMeanAggregator)(nil)
+
+// trimmedMeanAggregator removes the lowest and highest prices and
+// takes the weighted mean of the remaining ones.
+type trimmedMeanAggregator struct {
+	trim          float64
+	sourceWeights map[string]float64
+}
+
+// NewTrimmedMean returns the TrimmedMean Aggregator, trim is the fraction
+// of prices removed from each end, rounded down.
+func NewTrimmedMean(trim float64, sourceWeights map[string]float64) Aggregator {
+	return trimmedMeanAggregator{trim: trim, sourceWeights: sourceWeights}
+}
+
+func (t trimmedMeanAggregator) Aggregate(prices []types.Price) Result {
+	if len(prices) == 0 {
+		return Result{}
+	}
+
+	sorted := sortByPrice(prices)
+	n := int(t.trim * float64(len(sorted)))
+	kept := sorted[n : len(sorted)-n]
+	var dropped []types.Price
+	dropped = append(dropped, sorted[:n]...)
+	dropped = append(dropped, sorted[len(sorted)-n:]...)
+
+	return newResult(weightedMean(kept, Weights(kept, t.sourceWeights)), kept, dropped)
+}
@@ -0,0 +1,34 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestTrimmedMean(t *testing.T) {
+	prices := []types.Price{
+		{Price: sdkmath.LegacyMustNewDecFromStr("1.2"), SourceName: "high"},
+		{Price: sdkmath.LegacyMustNewDecFromStr("1.0"), SourceName: "a"},
+		{Price: sdkmath.LegacyMustNewDecFromStr("0.5"), SourceName: "low"},
+		{Price: sdkmath.LegacyMustNewDecFromStr("1.01"), SourceName: "b"},
+		{Price: sdkmath.LegacyMustNewDecFromStr("0.99"), SourceName: "c"},
+	}
+
+	t.Run("trims both ends", func(t *testing.T) {
+		result := NewTrimmedMean(0.2, nil).Aggregate(prices)
+		require.True(t, result.Valid())
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1.0").Equal(result.Price), "got %s", result.Price)
+		require.ElementsMatch(t, []string{"a", "b", "c"}, sources(result.Kept))
+		require.ElementsMatch(t, []string{"low", "high"}, sources(result.Dropped))
+	})
+
+	t.Run("keeps everything when there is nothing to trim", func(t *testing.T) {
+		result := NewTrimmedMean(0.1, nil).Aggregate(prices)
+		require.Len(t, result.Kept, 5)
+		require.Empty(t, result.Dropped)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("0.94").Equal(result.Price), "got %s", result.Price)
+	})
+}
@@ -0,0 +1,37 @@
+package aggregation
+
+import (
+	sdkmath "cosmossdk.io/math"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+var _ Aggregator = (*vwapAggregator)(nil)
+
+// vwapAggregator takes the volume weighted average of the prices,
+// the prices without volume are dropped.
+type vwapAggregator struct{}
+
+// NewVWAP returns the VWAP Aggregator.
+func NewVWAP() Aggregator {
+	return vwapAggregator{}
+}
+
+func (v vwapAggregator) Aggregate(prices []types.Price) Result {
+	var kept, dropped []types.Price
+	for _, p := range prices {
+		if p.Volume > 0 {
+			kept = append(kept, p)
+			continue
+		}
+		dropped = append(dropped, p)
+	}
+	if len(kept) == 0 {
+		return newResult(sdkmath.LegacyZeroDec(), nil, dropped)
+	}
+
+	volumes := make([]float64, len(kept))
+	for i, p := range kept {
+		volumes[i] = p.Volume
+	}
+	return newResult(weightedMean(kept, volumes), kept, dropped)
+}
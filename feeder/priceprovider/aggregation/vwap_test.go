@@ -0,0 +1,30 @@
+package aggregation
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+func TestVWAP(t *testing.T) {
+	t.Run("weights by volume", func(t *testing.T) {
+		result := NewVWAP().Aggregate([]types.Price{
+			{Price: sdkmath.LegacyMustNewDecFromStr("100"), Volume: 300, SourceName: "a"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("200"), Volume: 100, SourceName: "b"},
+			{Price: sdkmath.LegacyMustNewDecFromStr("1000"), SourceName: "c"},
+		})
+		require.True(t, result.Valid())
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("125.0").Equal(result.Price), "got %s", result.Price)
+		require.Equal(t, 400.0, result.Volume)
+		require.Equal(t, []string{"a", "b"}, sources(result.Kept))
+		require.Equal(t, []string{"c"}, sources(result.Dropped))
+	})
+
+	t.Run("invalid without volumes", func(t *testing.T) {
+		result := NewVWAP().Aggregate([]types.Price{{Price: sdkmath.LegacyMustNewDecFromStr("100"), SourceName: "a"}})
+		require.False(t, result.Valid())
+		require.Len(t, result.Dropped, 1)
+	})
+}
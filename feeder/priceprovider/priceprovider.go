@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/asset"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -14,6 +15,12 @@ import (
 
 var _ types.PriceProvider = (*PriceProvider)(nil)
 
+// streamConfig is the subset of a source's configuration
+// which selects between the polled and the streamed source.
+type streamConfig struct {
+	Websocket bool `json:"websocket"`
+}
+
 // PriceProvider implements the types.PriceProvider interface.
 // it wraps a Source and handles conversions between
 // asset pair to exchange symbols.
@@ -41,19 +48,31 @@ func NewPriceProvider(
 	case sources.Bitfinex:
 		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.BitfinexPriceUpdate, logger)
 	case sources.Binance:
-		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.BinancePriceUpdate, logger)
+		if useStream(config) {
+			source = sources.NewStreamSource(sources.BinanceStreamURL, mapValues(pairToSymbolMap), sources.BinanceStream, logger)
+		} else {
+			source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.BinancePriceUpdate, logger)
+		}
 	case sources.Coingecko:
 		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.CoingeckoPriceUpdate(config), logger)
 	case sources.Okex:
-		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.OkexPriceUpdate, logger)
+		if useStream(config) {
+			source = sources.NewStreamSource(sources.OkexStreamURL, mapValues(pairToSymbolMap), sources.OkexStream, logger)
+		} else {
+			source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.OkexPriceUpdate, logger)
+		}
 	case sources.GateIo:
 		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.GateIoPriceUpdate, logger)
 	case sources.CoinMarketCap:
 		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.CoinmarketcapPriceUpdate(config), logger)
 	case sources.Bybit:
-		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.BybitPriceUpdate, logger)
+		if useStream(config) {
+			source = sources.NewStreamSource(sources.BybitStreamURL, mapValues(pairToSymbolMap), sources.BybitStream, logger)
+		} else {
+			source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.BybitPriceUpdate, logger)
+		}
 	case sources.Uniswap:
-		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.UniswapPriceUpdate, logger)
+		source = sources.NewUniswapSource(mapValues(pairToSymbolMap), config, logger)
 	case sources.Mexc:
 		source = sources.NewTickSource(mapValues(pairToSymbolMap), sources.MexcPriceUpdate, logger)
 	case sources.Ascendex:
@@ -115,7 +134,7 @@ func (p *PriceProvider) GetPrice(pair asset.Pair) types.Price {
 		p.logger.Debug().Str("pair", pair.String()).Msg("pair not configured for this pricefeeder")
 		return types.Price{
 			Pair:       pair,
-			Price:      -1, // abstain
+			Price:      sdkmath.LegacyZeroDec(), // abstain
 			SourceName: p.sourceName,
 			Valid:      false,
 		}
@@ -128,6 +147,7 @@ func (p *PriceProvider) GetPrice(pair asset.Pair) types.Price {
 	return types.Price{
 		Pair:       pair,
 		Price:      price.Price,
+		Volume:     price.Volume,
 		SourceName: p.sourceName,
 		Valid:      isValid(price, priceExists),
 	}
@@ -147,6 +167,16 @@ func mapValues(m map[asset.Pair]types.Symbol) set.Set[types.Symbol] {
 	return s
 }
 
+// useStream reports whether the source config enables
+// the websocket implementation of the source.
+func useStream(config json.RawMessage) bool {
+	c := new(streamConfig)
+	if err := json.Unmarshal(config, c); err != nil {
+		return false
+	}
+	return c.Websocket
+}
+
 // isValid is a helper function which asserts if a price is valid given
 // if it was found and the time at which it was last updated.
 func isValid(price types.RawPrice, found bool) bool {
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/vsc-blockchain/core/x/common/asset"
@@ -58,7 +59,6 @@ func TestPriceProvider(t *testing.T) {
 		pp := newPriceProvider(testAsyncSource{}, "test", map[asset.Pair]types.Symbol{}, zerolog.New(io.Discard))
 		price := pp.GetPrice(asset.Registry.Pair(denoms.BTC, denoms.NUSD))
 		require.False(t, price.Valid)
-		require.Equal(t, float64(-1), price.Price)
 		require.Equal(t, asset.Registry.Pair(denoms.BTC, denoms.NUSD), price.Pair)
 	})
 
@@ -70,11 +70,11 @@ func TestPriceProvider(t *testing.T) {
 		}
 		pp := newPriceProvider(source, "test", map[asset.Pair]types.Symbol{asset.Registry.Pair(denoms.BTC, denoms.NUSD): "BTC:NUSD"}, zerolog.New(io.Discard))
 
-		priceUpdatesC <- map[types.Symbol]types.RawPrice{"BTC:NUSD": {Price: 10, UpdateTime: time.Now()}}
+		priceUpdatesC <- map[types.Symbol]types.RawPrice{"BTC:NUSD": {Price: sdkmath.LegacyMustNewDecFromStr("10"), UpdateTime: time.Now()}}
 		price := pp.GetPrice(asset.Registry.Pair(denoms.BTC, denoms.NUSD))
 
 		require.True(t, price.Valid)
-		require.Equal(t, float64(10), price.Price)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("10").Equal(price.Price), "got %s", price.Price)
 		require.Equal(t, asset.Registry.Pair(denoms.BTC, denoms.NUSD), price.Pair)
 		require.Equal(t, "test", price.SourceName)
 	})
@@ -95,21 +95,21 @@ func TestPriceProvider(t *testing.T) {
 func TestIsValid(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		require.True(t, isValid(types.RawPrice{
-			Price:      10,
+			Price:      sdkmath.LegacyMustNewDecFromStr("10"),
 			UpdateTime: time.Now(),
 		}, true))
 	})
 
 	t.Run("price not found", func(t *testing.T) {
 		require.False(t, isValid(types.RawPrice{
-			Price:      10,
+			Price:      sdkmath.LegacyMustNewDecFromStr("10"),
 			UpdateTime: time.Now(),
 		}, false))
 	})
 
 	t.Run("price expired", func(t *testing.T) {
 		require.False(t, isValid(types.RawPrice{
-			Price:      20,
+			Price:      sdkmath.LegacyMustNewDecFromStr("20"),
 			UpdateTime: time.Now().Add(-1 - 1*types.PriceTimeout),
 		}, true))
 	})
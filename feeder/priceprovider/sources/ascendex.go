@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -37,7 +36,7 @@ type AscendexResponse struct {
 
 // AscendexPriceUpdate returns the prices for given symbols or an error.
 // Check out the Ascendex API under https://ascendex.github.io/ascendex-pro-api/#ascendex-pro-api-documentation
-func AscendexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
+func AscendexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
 	url := "https://ascendex.com/api/pro/v1/spot/ticker"
 
 	resp, err := http.Get(url)
@@ -63,18 +62,19 @@ func AscendexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 
 	for _, ticker := range response.Data {
 		symbol := types.Symbol(ticker.Symbol)
-		price, err := strconv.ParseFloat(ticker.Close, 64)
+		price, err := types.ParsePrice(ticker.Close)
 		if err != nil {
 			logger.Err(err).Msgf("failed to parse price for %s on data source %s", symbol, Ascendex)
 			continue
 		}
 
+		// ascendex reports the volume in base asset
 		if _, ok := symbols[symbol]; ok {
-			rawPrices[symbol] = price
+			rawPrices[symbol] = types.RawPrice{Price: price, Volume: quoteVolume(ticker.Volume, price)}
 		}
 	}
 	logger.Debug().Msgf("fetched prices for %s on data source %s: %v", symbols, Ascendex, rawPrices)
@@ -15,7 +15,7 @@ func TestAscendexPriceUpdate(t *testing.T) {
 		rawPrices, err := AscendexPriceUpdate(set.New[types.Symbol]("BTC/USDT", "ETH/USDT"), zerolog.New(io.Discard))
 		require.NoError(t, err)
 		require.Equal(t, 2, len(rawPrices))
-		require.NotZero(t, rawPrices["BTC/USDT"])
-		require.NotZero(t, rawPrices["ETH/USDT"])
+		require.True(t, rawPrices["BTC/USDT"].Price.IsPositive())
+		require.True(t, rawPrices["ETH/USDT"].Price.IsPositive())
 	})
 }
@@ -19,7 +19,8 @@ var _ types.FetchPricesFunc = BinancePriceUpdate
 
 type BinanceTicker struct {
 	Symbol string  `json:"symbol"`
-	Price  float64 `json:"price,string"`
+	Price  string  `json:"lastPrice"`
+	Volume float64 `json:"quoteVolume,string"`
 }
 
 func BinanceSymbolCsv(symbols set.Set[types.Symbol]) string {
@@ -32,9 +33,9 @@ func BinanceSymbolCsv(symbols set.Set[types.Symbol]) string {
 }
 
 // BinancePriceUpdate returns the prices given the symbols or an error.
-// Uses the Binance API at https://docs.binance.us/#price-data.
-func BinancePriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
-	url := "https://api.binance.us/api/v3/ticker/price?symbols=%5B" + BinanceSymbolCsv(symbols) + "%5D"
+// Uses the Binance API at https://docs.binance.us/#24hr-ticker-price-change-statistics.
+func BinancePriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
+	url := "https://api.binance.us/api/v3/ticker/24hr?symbols=%5B" + BinanceSymbolCsv(symbols) + "%5D"
 	resp, err := http.Get(url)
 	if err != nil {
 		logger.Err(err).Msg("failed to fetch prices from Binance")
@@ -59,10 +60,15 @@ func BinancePriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (r
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 	for _, ticker := range tickers {
-		rawPrices[types.Symbol(ticker.Symbol)] = ticker.Price
-		logger.Debug().Msgf("fetched price for %s on data source %s: %f", ticker.Symbol, Binance, ticker.Price)
+		price, err := types.ParsePrice(ticker.Price)
+		if err != nil {
+			logger.Err(err).Msgf("failed to parse price for %s on data source %s", ticker.Symbol, Binance)
+			continue
+		}
+		rawPrices[types.Symbol(ticker.Symbol)] = types.RawPrice{Price: price, Volume: ticker.Volume}
+		logger.Debug().Msgf("fetched price for %s on data source %s: %s", ticker.Symbol, Binance, price)
 	}
 	metrics.PriceSourceCounter.WithLabelValues(Binance, "true").Inc()
 
@@ -0,0 +1,71 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vsc-blockchain/core/x/common/set"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// BinanceStreamURL is the Binance websocket endpoint, see https://docs.binance.us/#websocket-streams.
+const BinanceStreamURL = "wss://stream.binance.us:9443/ws"
+
+// BinanceStreamTicker is the 24hr ticker event pushed by Binance.
+type BinanceStreamTicker struct {
+	// Error is only set on the responses to the requests
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"msg"`
+	} `json:"error"`
+	Event  string  `json:"e"`
+	Symbol string  `json:"s"`
+	Price  string  `json:"c"`
+	Volume float64 `json:"q,string"`
+}
+
+// BinanceStream streams the 24hr tickers of the symbols from Binance.
+// Binance keeps the connection alive with ping frames, so no ping message is needed.
+var BinanceStream = StreamExchange{
+	Name:              Binance,
+	SubscribeMessages: binanceSubscribeMessages,
+	ParseMessage:      binanceParseMessage,
+}
+
+func binanceSubscribeMessages(symbols set.Set[types.Symbol]) ([][]byte, error) {
+	streams := make([]string, 0, len(symbols))
+	for symbol := range symbols {
+		streams = append(streams, strings.ToLower(string(symbol))+"@ticker")
+	}
+	msg, err := json.Marshal(map[string]any{
+		"method": "SUBSCRIBE",
+		"params": streams,
+		"id":     1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{msg}, nil
+}
+
+func binanceParseMessage(msg []byte) (map[types.Symbol]types.RawPrice, error) {
+	var ticker BinanceStreamTicker
+	if err := json.Unmarshal(msg, &ticker); err != nil {
+		return nil, err
+	}
+	if ticker.Error != nil {
+		return nil, fmt.Errorf("%w: %d %s", errStreamSubscription, ticker.Error.Code, ticker.Error.Message)
+	}
+	// subscription results are not ticker events
+	if ticker.Event != "24hrTicker" {
+		return nil, nil
+	}
+	price, err := types.ParsePrice(ticker.Price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse price for %s: %w", ticker.Symbol, err)
+	}
+	return map[types.Symbol]types.RawPrice{
+		types.Symbol(ticker.Symbol): {Price: price, Volume: ticker.Volume},
+	}, nil
+}
@@ -15,7 +15,7 @@ func TestBinanceSource(t *testing.T) {
 		rawPrices, err := BinancePriceUpdate(set.New[types.Symbol]("BTCUSD", "ETHUSD"), zerolog.New(io.Discard))
 		require.NoError(t, err)
 		require.Equal(t, 2, len(rawPrices))
-		require.NotZero(t, rawPrices["BTCUSD"])
-		require.NotZero(t, rawPrices["ETHUSD"])
+		require.True(t, rawPrices["BTCUSD"].Price.IsPositive())
+		require.True(t, rawPrices["ETHUSD"].Price.IsPositive())
 	})
 }
@@ -1,6 +1,7 @@
 package sources
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,10 +28,11 @@ func BitfinexSymbolCsv(symbols set.Set[types.Symbol]) string {
 }
 
 // BitfinexPriceUpdate returns the prices given the symbols or an error.
-func BitfinexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
+func BitfinexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
 	type ticker []interface{}
 	const size = 11
 	const lastPriceIndex = 7
+	const volumeIndex = 8
 	const symbolNameIndex = 0
 
 	var url string = "https://api-pub.bitfinex.com/v2/tickers?symbols=" + BitfinexSymbolCsv(symbols)
@@ -50,23 +52,42 @@ func BitfinexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (
 	}
 	var tickers []ticker
 
-	err = json.Unmarshal(b, &tickers)
+	// numbers are kept as json.Number so that prices are parsed exactly
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	err = decoder.Decode(&tickers)
 	if err != nil {
 		logger.Err(err).Msg("failed to unmarshal response body from Bitfinex")
 		metrics.PriceSourceCounter.WithLabelValues(Bitfinex, "false").Inc()
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 	for _, ticker := range tickers {
 		if len(ticker) != size {
 			return nil, fmt.Errorf("impossible to parse ticker size %d, %#v", len(ticker), ticker) // TODO(mercilex): return or log and continue?
 		}
-		symbol := types.Symbol(ticker[symbolNameIndex].(string))
-		lastPrice := ticker[lastPriceIndex].(float64)
+		symbolName, ok := ticker[symbolNameIndex].(string)
+		if !ok {
+			logger.Error().Msgf("invalid symbol %v on data source %s", ticker[symbolNameIndex], Bitfinex)
+			continue
+		}
+		symbol := types.Symbol(symbolName)
+		lastPriceNumber, ok := ticker[lastPriceIndex].(json.Number)
+		if !ok {
+			logger.Error().Msgf("missing price for %s on data source %s", symbol, Bitfinex)
+			continue
+		}
+		lastPrice, err := types.ParsePrice(lastPriceNumber.String())
+		if err != nil {
+			logger.Err(err).Msgf("failed to parse price for %s on data source %s", symbol, Bitfinex)
+			continue
+		}
+		// bitfinex reports the volume in base asset
+		volumeNumber, _ := ticker[volumeIndex].(json.Number)
 
-		rawPrices[symbol] = lastPrice
-		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %f", symbol, Bitfinex, lastPrice))
+		rawPrices[symbol] = types.RawPrice{Price: lastPrice, Volume: quoteVolume(volumeNumber.String(), lastPrice)}
+		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %s", symbol, Bitfinex, lastPrice))
 	}
 
 	metrics.PriceSourceCounter.WithLabelValues(Bitfinex, "true").Inc()
@@ -4,6 +4,8 @@ import (
 	"io"
 	"testing"
 
+	sdkmath "cosmossdk.io/math"
+	"github.com/jarcoal/httpmock"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -15,7 +17,27 @@ func TestBitfinexSource(t *testing.T) {
 		rawPrices, err := BitfinexPriceUpdate(set.New[types.Symbol]("tBTCUSD", "tETHUSD"), zerolog.New(io.Discard))
 		require.NoError(t, err)
 		require.Equal(t, 2, len(rawPrices))
-		require.NotZero(t, rawPrices["tBTCUSD"])
-		require.NotZero(t, rawPrices["tETHUSD"])
+		require.True(t, rawPrices["tBTCUSD"].Price.IsPositive())
+		require.True(t, rawPrices["tETHUSD"].Price.IsPositive())
 	})
 }
+
+func TestBitfinexSource_MissingValues(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET", "https://api-pub.bitfinex.com/v2/tickers?symbols=tBTCUSD",
+		httpmock.NewStringResponder(200, `[
+			["tBTCUSD",1,1,1,1,1,1,100000.5,null,1,1],
+			["tETHUSD",1,1,1,1,1,1,null,10,1,1],
+			[null,1,1,1,1,1,1,1,1,1,1]
+		]`),
+	)
+
+	rawPrices, err := BitfinexPriceUpdate(set.New[types.Symbol]("tBTCUSD"), zerolog.New(io.Discard))
+	require.NoError(t, err)
+	require.Equal(t, map[types.Symbol]types.RawPrice{
+		"tBTCUSD": {Price: sdkmath.LegacyMustNewDecFromStr("100000.5"), Volume: 0},
+	}, rawPrices)
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -23,13 +22,14 @@ type BybitResponse struct {
 		List []struct {
 			Symbol string `json:"symbol"`
 			Price  string `json:"lastPrice"`
+			Volume string `json:"turnover24h"`
 		} `json:"list"`
 	} `json:"result"`
 }
 
 // BybitPriceUpdate returns the prices for given symbols or an error.
 // Uses BYBIT API at https://bybit-exchange.github.io/docs/v5/market/tickers.
-func BybitPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
+func BybitPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
 	url := "https://api.bybit.com/v5/market/tickers?category=spot"
 
 	resp, err := http.Get(url)
@@ -55,18 +55,18 @@ func BybitPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (raw
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 
 	for _, ticker := range response.Data.List {
 		symbol := types.Symbol(ticker.Symbol)
-		price, err := strconv.ParseFloat(ticker.Price, 64)
+		price, err := types.ParsePrice(ticker.Price)
 		if err != nil {
 			logger.Err(err).Msgf("failed to parse price for %s on data source %s", symbol, Bybit)
 			continue
 		}
 
 		if _, ok := symbols[symbol]; ok {
-			rawPrices[symbol] = price
+			rawPrices[symbol] = types.RawPrice{Price: price, Volume: parseVolume(ticker.Volume)}
 		}
 	}
 	logger.Debug().Msgf("fetched prices for %s on data source %s: %v", symbols, Bybit, rawPrices)
@@ -0,0 +1,84 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vsc-blockchain/core/x/common/set"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// BybitStreamURL is the Bybit spot public websocket endpoint, see https://bybit-exchange.github.io/docs/v5/websocket/public/ticker.
+const BybitStreamURL = "wss://stream.bybit.com/v5/public/spot"
+
+// bybitMaxSubscribeArgs is the maximum number of topics
+// Bybit accepts in a single spot subscription request.
+const bybitMaxSubscribeArgs = 10
+
+type BybitStreamMessage struct {
+	// Success and RetMsg are only set on the responses to the requests
+	Success *bool  `json:"success"`
+	RetMsg  string `json:"ret_msg"`
+	Topic   string `json:"topic"`
+	Data    struct {
+		Symbol string `json:"symbol"`
+		Price  string `json:"lastPrice"`
+		Volume string `json:"turnover24h"`
+	} `json:"data"`
+}
+
+// BybitStream streams the tickers topic of the symbols from Bybit.
+// Bybit recommends a ping every 20 seconds to keep the connection alive.
+var BybitStream = StreamExchange{
+	Name:              Bybit,
+	SubscribeMessages: bybitSubscribeMessages,
+	PingMessage:       []byte(`{"op":"ping"}`),
+	PingInterval:      20 * time.Second,
+	ParseMessage:      bybitParseMessage,
+}
+
+func bybitSubscribeMessages(symbols set.Set[types.Symbol]) ([][]byte, error) {
+	topics := make([]string, 0, len(symbols))
+	for symbol := range symbols {
+		topics = append(topics, "tickers."+string(symbol))
+	}
+
+	var msgs [][]byte
+	for len(topics) > 0 {
+		n := min(len(topics), bybitMaxSubscribeArgs)
+		msg, err := json.Marshal(map[string]any{
+			"op":   "subscribe",
+			"args": topics[:n],
+		})
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+		topics = topics[n:]
+	}
+	return msgs, nil
+}
+
+func bybitParseMessage(msg []byte) (map[types.Symbol]types.RawPrice, error) {
+	var message BybitStreamMessage
+	if err := json.Unmarshal(msg, &message); err != nil {
+		return nil, err
+	}
+	if message.Success != nil && !*message.Success {
+		return nil, fmt.Errorf("%w: %s", errStreamSubscription, message.RetMsg)
+	}
+	// pongs and subscription results have no topic
+	if !strings.HasPrefix(message.Topic, "tickers.") {
+		return nil, nil
+	}
+
+	price, err := types.ParsePrice(message.Data.Price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse price for %s: %w", message.Data.Symbol, err)
+	}
+	return map[types.Symbol]types.RawPrice{
+		types.Symbol(message.Data.Symbol): {Price: price, Volume: parseVolume(message.Data.Volume)},
+	}, nil
+}
@@ -15,7 +15,7 @@ func TestBybitPriceUpdate(t *testing.T) {
 		rawPrices, err := BybitPriceUpdate(set.New[types.Symbol]("BTCUSDT", "ETHUSDT"), zerolog.New(io.Discard))
 		require.NoError(t, err)
 		require.Equal(t, 2, len(rawPrices))
-		require.NotZero(t, rawPrices["BTCUSDT"])
-		require.NotZero(t, rawPrices["ETHUSDT"])
+		require.True(t, rawPrices["BTCUSDT"].Price.IsPositive())
+		require.True(t, rawPrices["ETHUSDT"].Price.IsPositive())
 	})
 }
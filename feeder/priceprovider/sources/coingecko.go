@@ -21,7 +21,7 @@ const (
 )
 
 type CoingeckoTicker struct {
-	Price float64 `json:"usd"`
+	Price json.Number `json:"usd"`
 }
 
 type CoingeckoConfig struct {
@@ -29,7 +29,7 @@ type CoingeckoConfig struct {
 }
 
 func CoingeckoPriceUpdate(sourceConfig json.RawMessage) types.FetchPricesFunc {
-	return func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]float64, error) {
+	return func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 		c, err := extractConfig(sourceConfig)
 		if err != nil {
 			logger.Err(err).Msg("failed to extract coingecko config")
@@ -76,18 +76,23 @@ func extractConfig(jsonConfig json.RawMessage) (*CoingeckoConfig, error) {
 	return c, nil
 }
 
-func extractPricesFromResponse(symbols set.Set[types.Symbol], response []byte, logger zerolog.Logger) (map[types.Symbol]float64, error) {
+func extractPricesFromResponse(symbols set.Set[types.Symbol], response []byte, logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 	var result map[string]CoingeckoTicker
 	err := json.Unmarshal(response, &result)
 	if err != nil {
 		return nil, err
 	}
 
-	rawPrices := make(map[types.Symbol]float64)
+	rawPrices := make(map[types.Symbol]types.RawPrice)
 	for symbol := range symbols {
-		if price, ok := result[string(symbol)]; ok {
-			rawPrices[symbol] = price.Price
-			logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %f", symbol, Coingecko, price.Price))
+		if ticker, ok := result[string(symbol)]; ok {
+			price, err := types.ParsePrice(ticker.Price.String())
+			if err != nil {
+				logger.Err(err).Msg(fmt.Sprintf("failed to parse price for %s on data source %s", symbol, Coingecko))
+				continue
+			}
+			rawPrices[symbol] = types.RawPrice{Price: price}
+			logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %s", symbol, Coingecko, price))
 		} else {
 			logger.Err(fmt.Errorf("failed to parse price for %s on data source %s", symbol, Coingecko)).Msg(string(response))
 			continue
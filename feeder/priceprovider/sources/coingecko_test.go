@@ -5,6 +5,7 @@ import (
 	"io"
 	"testing"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/jarcoal/httpmock"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -31,8 +32,8 @@ func TestCoingeckoPriceUpdate(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(rawPrices))
-		require.Equal(t, rawPrices["bitcoin"], 23829.0)
-		require.Equal(t, rawPrices["ethereum"], 1676.85)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("23829").Equal(rawPrices["bitcoin"].Price), "got %s", rawPrices["bitcoin"].Price)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1676.85").Equal(rawPrices["ethereum"].Price), "got %s", rawPrices["ethereum"].Price)
 	})
 }
 
@@ -67,8 +68,8 @@ func TestCoingeckoWithConfig(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(rawPrices))
-		require.Equal(t, rawPrices["bitcoin"], 23829.0)
-		require.Equal(t, rawPrices["ethereum"], 1676.85)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("23829").Equal(rawPrices["bitcoin"].Price), "got %s", rawPrices["bitcoin"].Price)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1676.85").Equal(rawPrices["ethereum"].Price), "got %s", rawPrices["ethereum"].Price)
 	})
 
 	t.Run("providing config without api_key ignores and calls free endpoint", func(t *testing.T) {
@@ -92,7 +93,15 @@ func TestCoingeckoWithConfig(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(rawPrices))
-		require.Equal(t, rawPrices["bitcoin"], 23829.0)
-		require.Equal(t, rawPrices["ethereum"], 1676.85)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("23829").Equal(rawPrices["bitcoin"].Price), "got %s", rawPrices["bitcoin"].Price)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1676.85").Equal(rawPrices["ethereum"].Price), "got %s", rawPrices["ethereum"].Price)
 	})
 }
+
+func TestCoingeckoExactPrices(t *testing.T) {
+	response := []byte(`{"pepe":{"usd":1.2345678901234e-05},"bitcoin":{"usd":123456.123456789012345678}}`)
+	rawPrices, err := extractPricesFromResponse(set.New[types.Symbol]("pepe", "bitcoin"), response, zerolog.Nop())
+	require.NoError(t, err)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("0.000012345678901234").Equal(rawPrices["pepe"].Price), "got %s", rawPrices["pepe"].Price)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("123456.123456789012345678").Equal(rawPrices["bitcoin"].Price), "got %s", rawPrices["bitcoin"].Price)
+}
@@ -21,7 +21,8 @@ const (
 )
 
 type CmcQuotePrice struct {
-	Price float64
+	Price     json.Number
+	Volume24h float64 `json:"volume_24h"`
 }
 
 type CmcQuote struct {
@@ -42,7 +43,7 @@ type CoinmarketcapConfig struct {
 }
 
 func CoinmarketcapPriceUpdate(coinmarketcapConfig json.RawMessage) types.FetchPricesFunc {
-	return func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]float64, error) {
+	return func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 		config, err := getConfig(coinmarketcapConfig)
 		if err != nil {
 			logger.Err(err).Msg("failed to extract coinmarketcap config")
@@ -97,23 +98,28 @@ func getConfig(jsonConfig json.RawMessage) (*CoinmarketcapConfig, error) {
 	return c, nil
 }
 
-func getPricesFromResponse(symbols set.Set[types.Symbol], response []byte, logger zerolog.Logger) (map[types.Symbol]float64, error) {
+func getPricesFromResponse(symbols set.Set[types.Symbol], response []byte, logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 	var respCmc CmcResponse
 	err := json.Unmarshal(response, &respCmc)
 	if err != nil {
 		return nil, err
 	}
 
-	cmcPrice := make(map[string]float64)
+	cmcPrice := make(map[string]CmcQuotePrice)
 	for _, value := range respCmc.Data {
-		cmcPrice[value.Slug] = value.Quote.USD.Price
+		cmcPrice[value.Slug] = value.Quote.USD
 	}
 
-	rawPrices := make(map[types.Symbol]float64)
+	rawPrices := make(map[types.Symbol]types.RawPrice)
 	for symbol := range symbols {
-		if price, ok := cmcPrice[string(symbol)]; ok {
-			rawPrices[symbol] = price
-			logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %f", symbol, CoinMarketCap, price))
+		if quote, ok := cmcPrice[string(symbol)]; ok {
+			price, err := types.ParsePrice(quote.Price.String())
+			if err != nil {
+				logger.Err(err).Msg(fmt.Sprintf("failed to parse price for %s on data source %s", symbol, CoinMarketCap))
+				continue
+			}
+			rawPrices[symbol] = types.RawPrice{Price: price, Volume: quote.Volume24h}
+			logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %s", symbol, CoinMarketCap, price))
 		} else {
 			logger.Err(err).Msg(fmt.Sprintf("failed to parse price for %s on data source %s", symbol, CoinMarketCap))
 			continue
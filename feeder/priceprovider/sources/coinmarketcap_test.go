@@ -5,6 +5,7 @@ import (
 	"io"
 	"testing"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/jarcoal/httpmock"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -31,7 +32,7 @@ func TestCoinmarketcapPriceUpdate(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(rawPrices))
-		require.Equal(t, rawPrices["bitcoin"], 23829.0)
-		require.Equal(t, rawPrices["ethereum"], 1676.85)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("23829").Equal(rawPrices["bitcoin"].Price), "got %s", rawPrices["bitcoin"].Price)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("1676.85").Equal(rawPrices["ethereum"].Price), "got %s", rawPrices["ethereum"].Price)
 	})
 }
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -21,7 +20,7 @@ var _ types.FetchPricesFunc = GateIoPriceUpdate
 
 // GateIoPriceUpdate returns the prices given the symbols or an error.
 // Uses the GateIo API at https://www.gate.io/docs/developers/apiv4/en/#get-details-of-a-specifc-currency-pair.
-func GateIoPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
+func GateIoPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
 	url := "https://api.gateio.ws/api/v4/spot/tickers"
 	resp, err := http.Get(url)
 	if err != nil {
@@ -46,21 +45,22 @@ func GateIoPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (ra
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 	for _, ticker := range tickers {
 		symbol := types.Symbol(ticker["currency_pair"].(string))
 		if !symbols.Has(symbol) {
 			continue
 		}
 
-		price, err := strconv.ParseFloat(ticker["last"].(string), 64)
+		price, err := types.ParsePrice(ticker["last"].(string))
 		if err != nil {
 			logger.Err(err).Msg(fmt.Sprintf("failed to parse price for %s on data source %s", symbol, GateIo))
 			continue
 		}
 
-		rawPrices[symbol] = price
-		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %f", symbol, GateIo, price))
+		volume, _ := ticker["quote_volume"].(string)
+		rawPrices[symbol] = types.RawPrice{Price: price, Volume: parseVolume(volume)}
+		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %s", symbol, GateIo, price))
 	}
 
 	metrics.PriceSourceCounter.WithLabelValues(GateIo, "true").Inc()
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -20,13 +19,14 @@ var _ types.FetchPricesFunc = MexcPriceUpdate
 
 type MexcResponse []struct {
 	Symbol string `json:"symbol"`
-	Price  string `json:"price"`
+	Price  string `json:"lastPrice"`
+	Volume string `json:"quoteVolume"`
 }
 
 // MexcPriceUpdate returns the prices for given symbols or an error.
 // Check out the Mexc API under https://mexcdevelop.github.io/apidocs/spot_v3_en/#general-info
-func MexcPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
-	url := "https://api.mexc.com/api/v3/ticker/price"
+func MexcPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
+	url := "https://api.mexc.com/api/v3/ticker/24hr"
 
 	resp, err := http.Get(url)
 	if err != nil {
@@ -51,18 +51,18 @@ func MexcPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawP
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 
 	for _, ticker := range response {
 		symbol := types.Symbol(ticker.Symbol)
-		price, err := strconv.ParseFloat(ticker.Price, 64)
+		price, err := types.ParsePrice(ticker.Price)
 		if err != nil {
 			logger.Err(err).Msgf("failed to parse price for %s on data source %s", symbol, Mexc)
 			continue
 		}
 
 		if _, ok := symbols[symbol]; ok {
-			rawPrices[symbol] = price
+			rawPrices[symbol] = types.RawPrice{Price: price, Volume: parseVolume(ticker.Volume)}
 		}
 	}
 	logger.Debug().Msgf("fetched prices for %s on data source %s: %v", symbols, Mexc, rawPrices)
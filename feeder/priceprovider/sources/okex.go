@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -22,6 +21,7 @@ var _ types.FetchPricesFunc = OkexPriceUpdate
 type OkexTicker struct {
 	Symbol string `json:"instId"`
 	Price  string `json:"last"`
+	Volume string `json:"volCcy24h"`
 }
 
 type OkexResponse struct {
@@ -30,7 +30,7 @@ type OkexResponse struct {
 
 // OkexPriceUpdate returns the prices for given symbols or an error.
 // Uses OKEX API at https://www.okx.com/docs-v5/en/#rest-api-market-data.
-func OkexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
+func OkexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]types.RawPrice, err error) {
 	url := "https://www.okx.com/api/v5/market/tickers?instType=SPOT"
 
 	resp, err := http.Get(url)
@@ -56,7 +56,7 @@ func OkexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawP
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices = make(map[types.Symbol]types.RawPrice)
 	for _, ticker := range response.Data {
 
 		symbol := types.Symbol(ticker.Symbol)
@@ -64,14 +64,14 @@ func OkexPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawP
 			continue
 		}
 
-		price, err := strconv.ParseFloat(ticker.Price, 64)
+		price, err := types.ParsePrice(ticker.Price)
 		if err != nil {
 			logger.Err(err).Msg(fmt.Sprintf("failed to parse price for %s on data source %s", symbol, Okex))
 			continue
 		}
 
-		rawPrices[symbol] = price
-		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %f", symbol, Okex, price))
+		rawPrices[symbol] = types.RawPrice{Price: price, Volume: parseVolume(ticker.Volume)}
+		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %s", symbol, Okex, price))
 	}
 
 	metrics.PriceSourceCounter.WithLabelValues(Okex, "true").Inc()
@@ -0,0 +1,70 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vsc-blockchain/core/x/common/set"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// OkexStreamURL is the OKX public websocket endpoint, see https://www.okx.com/docs-v5/en/#websocket-api.
+const OkexStreamURL = "wss://ws.okx.com:8443/ws/v5/public"
+
+type OkexStreamMessage struct {
+	Event   string       `json:"event"`
+	Code    string       `json:"code"`
+	Message string       `json:"msg"`
+	Data    []OkexTicker `json:"data"`
+}
+
+// OkexStream streams the tickers channel of the symbols from OKX.
+// OKX closes connections which are silent for 30 seconds, hence the ping.
+var OkexStream = StreamExchange{
+	Name:              Okex,
+	SubscribeMessages: okexSubscribeMessages,
+	PingMessage:       []byte("ping"),
+	PingInterval:      20 * time.Second,
+	ParseMessage:      okexParseMessage,
+}
+
+func okexSubscribeMessages(symbols set.Set[types.Symbol]) ([][]byte, error) {
+	args := make([]map[string]string, 0, len(symbols))
+	for symbol := range symbols {
+		args = append(args, map[string]string{"channel": "tickers", "instId": string(symbol)})
+	}
+	msg, err := json.Marshal(map[string]any{
+		"op":   "subscribe",
+		"args": args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{msg}, nil
+}
+
+func okexParseMessage(msg []byte) (map[types.Symbol]types.RawPrice, error) {
+	if string(msg) == "pong" {
+		return nil, nil
+	}
+
+	var message OkexStreamMessage
+	if err := json.Unmarshal(msg, &message); err != nil {
+		return nil, err
+	}
+	if message.Event == "error" {
+		return nil, fmt.Errorf("%w: %s %s", errStreamSubscription, message.Code, message.Message)
+	}
+
+	// subscription events carry no data
+	rawPrices := make(map[types.Symbol]types.RawPrice, len(message.Data))
+	for _, ticker := range message.Data {
+		price, err := types.ParsePrice(ticker.Price)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse price for %s: %w", ticker.Symbol, err)
+		}
+		rawPrices[types.Symbol(ticker.Symbol)] = types.RawPrice{Price: price, Volume: parseVolume(ticker.Volume)}
+	}
+	return rawPrices, nil
+}
@@ -0,0 +1,322 @@
+package sources
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/vsc-blockchain/core/x/common/set"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+var (
+	// StreamMinBackoff defines the initial wait time before reconnecting a StreamSource.
+	StreamMinBackoff = 1 * time.Second
+	// StreamMaxBackoff defines the maximum wait time before reconnecting a StreamSource.
+	StreamMaxBackoff = 1 * time.Minute
+	// StreamReadTimeout defines the maximum time without messages
+	// after which a StreamSource connection is considered dead.
+	StreamReadTimeout = 1 * time.Minute
+	// StreamRefreshInterval defines the interval at which the last prices received on a
+	// connection are sent again while it is alive, as of the time they were received.
+	StreamRefreshInterval = 5 * time.Second
+	// StreamMaxPriceAge defines the age after which a price is no longer sent again, so that
+	// the price of a symbol which stopped ticking, e.g. halted or unsubscribed, goes stale.
+	StreamMaxPriceAge = types.PriceTimeout
+)
+
+var _ types.Source = (*StreamSource)(nil)
+
+// errStreamSubscription is returned by StreamExchange.ParseMessage
+// for the messages rejecting a subscription request.
+var errStreamSubscription = errors.New("subscription rejected")
+
+// StreamExchange defines the exchange specific
+// behaviour of a StreamSource.
+type StreamExchange struct {
+	// Name is the name of the exchange source.
+	Name string
+	// SubscribeMessages returns the messages to send, once connected,
+	// in order to subscribe to the tickers of the given symbols.
+	SubscribeMessages func(symbols set.Set[types.Symbol]) ([][]byte, error)
+	// PingMessage, if not nil, is sent every PingInterval
+	// to keep the connection alive.
+	PingMessage  []byte
+	PingInterval time.Duration
+	// ParseMessage returns the price updates contained in the message,
+	// messages which are not ticker updates return no prices and no error,
+	// and errors rejecting a subscription return an errStreamSubscription.
+	ParseMessage func(msg []byte) (map[types.Symbol]types.RawPrice, error)
+}
+
+// NewStreamSource instantiates a new StreamSource instance, given the websocket url, the symbols
+// and the exchange specific message handling.
+func NewStreamSource(url string, symbols set.Set[types.Symbol], exchange StreamExchange, logger zerolog.Logger) *StreamSource {
+	s := &StreamSource{
+		logger:             logger.With().Str("component", "stream-source").Str("source", exchange.Name).Logger(),
+		url:                url,
+		symbols:            symbols,
+		exchange:           exchange,
+		stopSignal:         make(chan struct{}),
+		done:               make(chan struct{}),
+		connectionMutex:    sync.Mutex{},
+		priceUpdateChannel: make(chan map[types.Symbol]types.RawPrice),
+	}
+
+	go s.loop()
+
+	return s
+}
+
+// StreamSource is a Source which receives the prices
+// pushed by an exchange over a websocket connection.
+// It reconnects with exponential backoff on failures.
+type StreamSource struct {
+	logger             zerolog.Logger
+	url                string
+	symbols            set.Set[types.Symbol] // symbols as named on the third party data source
+	exchange           StreamExchange
+	stopSignal         chan struct{} // external signal to stop the loop
+	done               chan struct{} // internal signal to wait for shutdown operations
+	connectionMutex    sync.Mutex
+	connection         *websocket.Conn
+	closed             bool
+	priceUpdateChannel chan map[types.Symbol]types.RawPrice
+}
+
+func (s *StreamSource) loop() {
+	defer close(s.done)
+
+	backoff := StreamMinBackoff
+	for {
+		connection, err := s.connect()
+		if err == nil {
+			backoff = StreamMinBackoff
+			err = s.read(connection)
+			connection.Close()
+		}
+
+		select {
+		case <-s.stopSignal:
+			return
+		default:
+		}
+
+		s.logger.Err(err).Dur("backoff", backoff).Msg("websocket disconnected, reconnecting")
+		metrics.PriceSourceCounter.WithLabelValues(s.exchange.Name, "false").Inc()
+		select {
+		case <-s.stopSignal:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > StreamMaxBackoff {
+			backoff = StreamMaxBackoff
+		}
+	}
+}
+
+// connect dials the websocket and subscribes to the symbols.
+func (s *StreamSource) connect() (*websocket.Conn, error) {
+	subscribeMessages, err := s.exchange.SubscribeMessages(s.symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	connection, _, err := websocket.DefaultDialer.Dial(s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.connectionMutex.Lock()
+	if s.closed {
+		s.connectionMutex.Unlock()
+		connection.Close()
+		return nil, websocket.ErrCloseSent
+	}
+	s.connection = connection
+	s.connectionMutex.Unlock()
+
+	for _, msg := range subscribeMessages {
+		if err := connection.WriteMessage(websocket.TextMessage, msg); err != nil {
+			connection.Close()
+			return nil, err
+		}
+	}
+
+	s.logger.Debug().Msg("connected to websocket")
+	return connection, nil
+}
+
+// read forwards the price updates received on the connection
+// until it fails or the StreamSource is closed.
+func (s *StreamSource) read(connection *websocket.Conn) error {
+	stopPing := make(chan struct{})
+	defer close(stopPing)
+	if s.exchange.PingMessage != nil {
+		go s.ping(connection, stopPing)
+	}
+
+	// the deadline is extended on every message, including control ones,
+	// so that half-open connections are detected.
+	extendDeadline := func() error {
+		return connection.SetReadDeadline(time.Now().Add(StreamReadTimeout))
+	}
+	connection.SetPingHandler(func(data string) error {
+		_ = extendDeadline()
+		return connection.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// the last prices are only refreshed during the connection they were received on
+	latest := &latestPrices{prices: map[types.Symbol]types.RawPrice{}}
+	stopRefresh := make(chan struct{})
+	defer close(stopRefresh)
+	go s.refresh(latest, StreamRefreshInterval, StreamMaxPriceAge, stopRefresh)
+
+	for {
+		if err := extendDeadline(); err != nil {
+			return err
+		}
+		_, msg, err := connection.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		updates, err := s.exchange.ParseMessage(msg)
+		if errors.Is(err, errStreamSubscription) {
+			// the connection stays up for the other symbols
+			s.logger.Err(err).Bytes("payload", msg).Msg("failed to subscribe")
+			metrics.PriceSourceCounter.WithLabelValues(s.exchange.Name, "false").Inc()
+			continue
+		}
+		if err != nil {
+			s.logger.Err(err).Bytes("payload", msg).Msg("failed to parse message")
+			continue
+		}
+
+		priceUpdate := make(map[types.Symbol]types.RawPrice, len(updates))
+		for symbol, price := range updates {
+			if !s.symbols.Has(symbol) {
+				continue
+			}
+			price.UpdateTime = time.Now()
+			priceUpdate[symbol] = price
+		}
+		if len(priceUpdate) == 0 {
+			continue
+		}
+		metrics.PriceSourceCounter.WithLabelValues(s.exchange.Name, "true").Inc()
+
+		if !latest.send(priceUpdate, s.priceUpdateChannel, s.stopSignal) {
+			s.logger.Warn().Msg("dropped price update due to shutdown")
+			return nil
+		}
+		s.logger.Debug().Interface("prices", priceUpdate).Msg("sent price update")
+	}
+}
+
+// refresh sends again the last prices younger than maxAge every interval, until stop is closed.
+func (s *StreamSource) refresh(latest *latestPrices, interval, maxAge time.Duration, stop <-chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.stopSignal:
+			return
+		case <-tick.C:
+			if latest.refresh(maxAge, s.priceUpdateChannel, stop, s.stopSignal) {
+				s.logger.Debug().Msg("refreshed last prices")
+			}
+		}
+	}
+}
+
+// latestPrices are the last prices received on a connection. Sending them under
+// the lock keeps a refresh from overwriting a newer update with an older price.
+type latestPrices struct {
+	mu     sync.Mutex
+	prices map[types.Symbol]types.RawPrice
+}
+
+// send records and sends the update, unless stopped first.
+func (l *latestPrices) send(update map[types.Symbol]types.RawPrice, updates chan<- map[types.Symbol]types.RawPrice, stop <-chan struct{}) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for symbol, price := range update {
+		l.prices[symbol] = price
+	}
+	select {
+	case updates <- update:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// refresh sends the last prices younger than maxAge, if any, unless the connection is done
+// or the source is closed first. Their update time remains the time they were received at,
+// and older prices are forgotten.
+func (l *latestPrices) refresh(maxAge time.Duration, updates chan<- map[types.Symbol]types.RawPrice, done, stop <-chan struct{}) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	update := make(map[types.Symbol]types.RawPrice, len(l.prices))
+	for symbol, price := range l.prices {
+		if time.Since(price.UpdateTime) >= maxAge {
+			delete(l.prices, symbol)
+			continue
+		}
+		update[symbol] = price
+	}
+	if len(update) == 0 {
+		return false
+	}
+	select {
+	case updates <- update:
+		return true
+	case <-done:
+		return false
+	case <-stop:
+		return false
+	}
+}
+
+// ping keeps the connection alive by sending the ping message of the exchange.
+func (s *StreamSource) ping(connection *websocket.Conn, stop <-chan struct{}) {
+	tick := time.NewTicker(s.exchange.PingInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+			if err := connection.WriteMessage(websocket.TextMessage, s.exchange.PingMessage); err != nil {
+				s.logger.Err(err).Msg("failed to send ping")
+				return
+			}
+		}
+	}
+}
+
+func (s *StreamSource) PriceUpdates() <-chan map[types.Symbol]types.RawPrice {
+	return s.priceUpdateChannel
+}
+
+func (s *StreamSource) Close() {
+	close(s.stopSignal)
+
+	s.connectionMutex.Lock()
+	s.closed = true
+	if s.connection != nil {
+		s.connection.Close()
+	}
+	s.connectionMutex.Unlock()
+
+	<-s.done
+}
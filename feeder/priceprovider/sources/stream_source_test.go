@@ -0,0 +1,283 @@
+package sources
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/vsc-blockchain/core/x/common/set"
+	"github.com/vsc-blockchain/pricefeeder/metrics"
+	"github.com/vsc-blockchain/pricefeeder/types"
+)
+
+// newStreamServer starts a local websocket server which calls handle for every connection.
+func newStreamServer(t *testing.T, handle func(conn *websocket.Conn, connection int)) string {
+	var connections int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		handle(conn, int(atomic.AddInt32(&connections, 1)))
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func receivePrices(t *testing.T, s *StreamSource) map[types.Symbol]types.RawPrice {
+	select {
+	case prices := <-s.PriceUpdates():
+		return prices
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout when receiving prices")
+		return nil
+	}
+}
+
+func TestStreamSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		exchange  StreamExchange
+		symbol    types.Symbol
+		subscribe string
+		messages  []string
+	}{
+		{
+			name:      Binance,
+			exchange:  BinanceStream,
+			symbol:    "BTCUSDT",
+			subscribe: `{"id":1,"method":"SUBSCRIBE","params":["btcusdt@ticker"]}`,
+			messages: []string{
+				`{"result":null,"id":1}`,
+				`{"e":"24hrTicker","s":"BTCUSDT","c":"42000.5","q":"1000000"}`,
+			},
+		},
+		{
+			name:      Okex,
+			exchange:  OkexStream,
+			symbol:    "BTC-USDT",
+			subscribe: `{"args":[{"channel":"tickers","instId":"BTC-USDT"}],"op":"subscribe"}`,
+			messages: []string{
+				`{"event":"subscribe","arg":{"channel":"tickers","instId":"BTC-USDT"}}`,
+				`pong`,
+				`{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","last":"42000.5","volCcy24h":"1000000"}]}`,
+			},
+		},
+		{
+			name:      Bybit,
+			exchange:  BybitStream,
+			symbol:    "BTCUSDT",
+			subscribe: `{"args":["tickers.BTCUSDT"],"op":"subscribe"}`,
+			messages: []string{
+				`{"success":true,"ret_msg":"subscribe","op":"subscribe"}`,
+				`{"topic":"tickers.BTCUSDT","type":"snapshot","data":{"symbol":"BTCUSDT","lastPrice":"42000.5","turnover24h":"1000000"}}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			url := newStreamServer(t, func(conn *websocket.Conn, _ int) {
+				_, msg, err := conn.ReadMessage()
+				require.NoError(t, err)
+				require.JSONEq(t, tc.subscribe, string(msg))
+				for _, m := range tc.messages {
+					require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(m)))
+				}
+				// hold the connection until the client goes away
+				_, _, _ = conn.ReadMessage()
+			})
+
+			s := NewStreamSource(url, set.New(tc.symbol), tc.exchange, zerolog.New(io.Discard))
+			defer s.Close()
+
+			prices := receivePrices(t, s)
+			require.Len(t, prices, 1)
+			require.True(t, sdkmath.LegacyMustNewDecFromStr("42000.5").Equal(prices[tc.symbol].Price), "got %s", prices[tc.symbol].Price)
+			require.Equal(t, 1_000_000.0, prices[tc.symbol].Volume)
+			require.True(t, time.Since(prices[tc.symbol].UpdateTime) < time.Second)
+		})
+	}
+}
+
+func TestStreamSource_Reconnect(t *testing.T) {
+	StreamMinBackoff = 10 * time.Millisecond
+	defer func() { StreamMinBackoff = 1 * time.Second }()
+
+	url := newStreamServer(t, func(conn *websocket.Conn, connection int) {
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		// the first connection drops right after subscribing
+		if connection == 1 {
+			return
+		}
+		msg := `{"e":"24hrTicker","s":"BTCUSDT","c":"42000.5","q":"1000000"}`
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		_, _, _ = conn.ReadMessage()
+	})
+
+	s := NewStreamSource(url, set.New[types.Symbol]("BTCUSDT"), BinanceStream, zerolog.New(io.Discard))
+	defer s.Close()
+
+	prices := receivePrices(t, s)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("42000.5").Equal(prices["BTCUSDT"].Price), "got %s", prices["BTCUSDT"].Price)
+}
+
+func TestStreamSource_FiltersSymbols(t *testing.T) {
+	url := newStreamServer(t, func(conn *websocket.Conn, _ int) {
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		for _, msg := range []string{
+			`{"e":"24hrTicker","s":"ETHUSDT","c":"2000","q":"1000"}`,
+			`{"e":"24hrTicker","s":"BTCUSDT","c":"42000.5","q":"1000000"}`,
+		} {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		}
+		_, _, _ = conn.ReadMessage()
+	})
+
+	s := NewStreamSource(url, set.New[types.Symbol]("BTCUSDT"), BinanceStream, zerolog.New(io.Discard))
+	defer s.Close()
+
+	prices := receivePrices(t, s)
+	require.Len(t, prices, 1)
+	require.Contains(t, prices, types.Symbol("BTCUSDT"))
+}
+
+func TestStreamSource_RefreshesPrices(t *testing.T) {
+	StreamRefreshInterval = 20 * time.Millisecond
+	StreamMaxPriceAge = 200 * time.Millisecond
+	defer func() {
+		StreamRefreshInterval = 5 * time.Second
+		StreamMaxPriceAge = types.PriceTimeout
+	}()
+
+	url := newStreamServer(t, func(conn *websocket.Conn, _ int) {
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+		// the ticker is only pushed once, as it stops ticking
+		msg := `{"topic":"tickers.BTCUSDT","type":"snapshot","data":{"symbol":"BTCUSDT","lastPrice":"42000.5","turnover24h":"1000000"}}`
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		_, _, _ = conn.ReadMessage()
+	})
+
+	s := NewStreamSource(url, set.New[types.Symbol]("BTCUSDT"), BybitStream, zerolog.New(io.Discard))
+	defer s.Close()
+
+	// the price is sent again as of the time it was received
+	first := receivePrices(t, s)
+	refreshed := receivePrices(t, s)
+	require.Equal(t, first, refreshed)
+
+	// until it is older than the max age
+	deadline := first["BTCUSDT"].UpdateTime.Add(StreamMaxPriceAge)
+	for {
+		select {
+		case prices := <-s.PriceUpdates():
+			require.True(t, time.Now().Before(deadline.Add(StreamRefreshInterval)), "price sent after the max age")
+			require.Equal(t, first, prices)
+		case <-time.After(5 * StreamRefreshInterval):
+			require.True(t, time.Now().After(deadline), "price no longer sent before the max age")
+			return
+		}
+	}
+}
+
+func TestStreamSource_SubscriptionErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		exchange StreamExchange
+		symbol   types.Symbol
+		messages []string
+	}{
+		{
+			name:     Binance,
+			exchange: BinanceStream,
+			symbol:   "BTCUSDT",
+			messages: []string{
+				`{"error":{"code":2,"msg":"Invalid request"},"id":1}`,
+				`{"e":"24hrTicker","s":"BTCUSDT","c":"42000.5","q":"1000000"}`,
+			},
+		},
+		{
+			name:     Okex,
+			exchange: OkexStream,
+			symbol:   "BTC-USDT",
+			messages: []string{
+				`{"event":"error","code":"60018","msg":"Wrong URL or channel:tickers,instId:FOO-USDT doesn't exist."}`,
+				`{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","last":"42000.5","volCcy24h":"1000000"}]}`,
+			},
+		},
+		{
+			name:     Bybit,
+			exchange: BybitStream,
+			symbol:   "BTCUSDT",
+			messages: []string{
+				`{"success":false,"ret_msg":"Invalid symbol :[tickers.FOOUSDT]","op":"subscribe"}`,
+				`{"topic":"tickers.BTCUSDT","type":"snapshot","data":{"symbol":"BTCUSDT","lastPrice":"42000.5","turnover24h":"1000000"}}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.exchange.ParseMessage([]byte(tc.messages[0]))
+			require.ErrorIs(t, err, errStreamSubscription)
+
+			url := newStreamServer(t, func(conn *websocket.Conn, _ int) {
+				_, _, err := conn.ReadMessage()
+				require.NoError(t, err)
+				for _, m := range tc.messages {
+					require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(m)))
+				}
+				_, _, _ = conn.ReadMessage()
+			})
+
+			failures := metrics.PriceSourceCounter.WithLabelValues(tc.exchange.Name, "false")
+			before := testutil.ToFloat64(failures)
+
+			s := NewStreamSource(url, set.New(tc.symbol), tc.exchange, zerolog.New(io.Discard))
+			defer s.Close()
+
+			// the rejected subscription is counted, and the other symbols are still streamed
+			prices := receivePrices(t, s)
+			require.True(t, sdkmath.LegacyMustNewDecFromStr("42000.5").Equal(prices[tc.symbol].Price), "got %s", prices[tc.symbol].Price)
+			require.Equal(t, before+1, testutil.ToFloat64(failures))
+		})
+	}
+}
+
+func TestStreamSource_CloseWhileDisconnected(t *testing.T) {
+	s := NewStreamSource("ws://127.0.0.1:1", set.New[types.Symbol]("BTCUSDT"), BinanceStream, zerolog.New(io.Discard))
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout when closing the source")
+	}
+}
+
+func TestBybitSubscribeMessages(t *testing.T) {
+	symbols := set.New[types.Symbol]()
+	for i := 0; i < 25; i++ {
+		symbols.Add(types.Symbol(strings.Repeat("A", i+1) + "USDT"))
+	}
+	msgs, err := bybitSubscribeMessages(symbols)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+}
@@ -39,7 +39,7 @@ type TickSource struct {
 	done               chan struct{} // internal signal to wait for shutdown operations
 	tick               *time.Ticker
 	symbols            set.Set[types.Symbol] // symbols as named on the third party data source
-	fetchPrices        func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]float64, error)
+	fetchPrices        types.FetchPricesFunc
 	priceUpdateChannel chan map[types.Symbol]types.RawPrice
 }
 
@@ -63,7 +63,8 @@ func (s *TickSource) loop() {
 			priceUpdate := make(map[types.Symbol]types.RawPrice, len(rawPrices))
 			for symbol, price := range rawPrices {
 				priceUpdate[symbol] = types.RawPrice{
-					Price:      price,
+					Price:      price.Price,
+					Volume:     price.Volume,
 					UpdateTime: time.Now(),
 				}
 			}
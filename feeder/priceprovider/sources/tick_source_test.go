@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -24,10 +25,10 @@ func (m mockWriter) Write(p []byte) (n int, err error) { return m.w(p) }
 func TestTickSource(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		expectedSymbols := set.New[types.Symbol]("tBTCUSDT")
-		expectedPrices := map[types.Symbol]float64{"tBTCUSDT": 250_000.56}
+		expectedPrices := map[types.Symbol]types.RawPrice{"tBTCUSDT": {Price: sdkmath.LegacyMustNewDecFromStr("250000.56"), Volume: 1_000_000}}
 
 		ts := NewTickSource(expectedSymbols,
-			func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]float64, error) {
+			func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 				require.Equal(t, expectedSymbols, symbols)
 				return expectedPrices, nil
 			}, zerolog.New(io.Discard))
@@ -43,7 +44,8 @@ func TestTickSource(t *testing.T) {
 
 		require.Equal(t, len(expectedPrices), len(gotPrices))
 		for symbol, price := range expectedPrices {
-			require.Equal(t, price, gotPrices[symbol].Price)
+			require.True(t, price.Price.Equal(gotPrices[symbol].Price), "got %s", gotPrices[symbol].Price)
+			require.Equal(t, price.Volume, gotPrices[symbol].Volume)
 			require.True(t, time.Since(gotPrices[symbol].UpdateTime) < 50*time.Millisecond)
 		}
 	})
@@ -60,9 +62,9 @@ func TestTickSource(t *testing.T) {
 		}
 
 		expectedSymbols := set.New[types.Symbol]("tBTCUSDT")
-		expectedPrices := map[types.Symbol]float64{"tBTCUSDT": 250_000.56}
+		expectedPrices := map[types.Symbol]types.RawPrice{"tBTCUSDT": {Price: sdkmath.LegacyMustNewDecFromStr("250000.56"), Volume: 1_000_000}}
 
-		ts := NewTickSource(expectedSymbols, func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]float64, error) {
+		ts := NewTickSource(expectedSymbols, func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 			return expectedPrices, nil
 		}, zerolog.New(mw))
 
@@ -81,7 +83,7 @@ func TestTickSource(t *testing.T) {
 			return written, nil
 		}}
 
-		ts := NewTickSource(set.New[types.Symbol]("tBTCUSDT"), func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]float64, error) {
+		ts := NewTickSource(set.New[types.Symbol]("tBTCUSDT"), func(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
 			return nil, fmt.Errorf("sentinel error")
 		}, zerolog.New(mw))
 		defer ts.Close()
@@ -2,14 +2,18 @@ package sources
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/ethereum/go-ethereum"
+	sdkmath "cosmossdk.io/math"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/set"
 	"github.com/vsc-blockchain/pricefeeder/metrics"
@@ -20,132 +24,759 @@ const (
 	Uniswap = "uniswap"
 )
 
-var _ types.FetchPricesFunc = UniswapPriceUpdate
+const (
+	UniswapV2 = "v2"
+	UniswapV3 = "v3"
+)
 
 const (
 	publicNodeURL      = "https://ethereum-rpc.publicnode.com"
-	uniswapPairABIJSON = `[{"constant":true,"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint112","name":"_reserve0","type":"uint112"},{"internalType":"uint112","name":"_reserve1","type":"uint112"},{"internalType":"uint32","name":"_blockTimestampLast","type":"uint32"}],"payable":false,"stateMutability":"view","type":"function"}]`
+	uniswapPairABIJSON = `[{"constant":true,"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint112","name":"_reserve0","type":"uint112"},{"internalType":"uint112","name":"_reserve1","type":"uint112"},{"internalType":"uint32","name":"_blockTimestampLast","type":"uint32"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"price0CumulativeLast","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"price1CumulativeLast","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}]`
+	uniswapPoolABIJSON = `[{"inputs":[],"name":"slot0","outputs":[{"internalType":"uint160","name":"sqrtPriceX96","type":"uint160"},{"internalType":"int24","name":"tick","type":"int24"},{"internalType":"uint16","name":"observationIndex","type":"uint16"},{"internalType":"uint16","name":"observationCardinality","type":"uint16"},{"internalType":"uint16","name":"observationCardinalityNext","type":"uint16"},{"internalType":"uint8","name":"feeProtocol","type":"uint8"},{"internalType":"bool","name":"unlocked","type":"bool"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint32[]","name":"secondsAgos","type":"uint32[]"}],"name":"observe","outputs":[{"internalType":"int56[]","name":"tickCumulatives","type":"int56[]"},{"internalType":"uint160[]","name":"secondsPerLiquidityCumulativeX128s","type":"uint160[]"}],"stateMutability":"view","type":"function"}]`
 	ethUsdtPairAddress = "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852" // ETH/USDT Uniswap V2 pair contract address
 	ethUsdcPairAddress = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc" // ETH/USDC Uniswap V2 pair contract address
 	vsgEthPairAddress  = "0x1E9348B71EcBaaa14EFF7B4B6186B78d1A9B9B70" // VSG/ETH pair contract address
 )
 
-// UniswapPriceUpdate returns the prices for given symbols or an error.
-func UniswapPriceUpdate(symbols set.Set[types.Symbol], logger zerolog.Logger) (rawPrices map[types.Symbol]float64, err error) {
-	client, err := ethclient.Dial(publicNodeURL)
-	if err != nil {
-		logger.Err(err).Msg("failed to connect to the Ethereum client")
+// uniswapMaxRouteDeviation is the maximum ratio between the prices
+// of the routes of a symbol, beyond which the symbol is not priced.
+const uniswapMaxRouteDeviation = 1.25
+
+// uniswapMaxDecimals is the largest number of decimals of a token,
+// 10^77 being the largest power of ten held by a uint256 amount.
+const uniswapMaxDecimals = 77
+
+// uniswapPricePrecision is the precision, in bits, of the pool and route prices,
+// enough to carry the 18 decimals of the consolidated price.
+const uniswapPricePrecision = 256
+
+var (
+	// UniswapRequestTimeout defines the timeout of each request to the RPC endpoint.
+	UniswapRequestTimeout = 5 * time.Second
+	// UniswapMaxBlockAge defines the age of the latest block
+	// beyond which an RPC endpoint is considered to be lagging.
+	UniswapMaxBlockAge = 5 * time.Minute
+)
+
+// UniswapPool is a single pool of a route.
+// The pool prices its token0 in token1, unless Inverse is set.
+type UniswapPool struct {
+	Address        string `json:"address"`
+	Version        string `json:"version"` // v2 or v3, defaults to v2
+	Token0Decimals int64  `json:"token0_decimals"`
+	Token1Decimals int64  `json:"token1_decimals"`
+	Inverse        bool   `json:"inverse"`
+}
+
+// UniswapSymbol defines how a symbol is priced. The price of a route is the product
+// of the prices of its pools, and the price of the symbol is the average of its routes.
+// When TWAPWindow is set, pools are priced by their time weighted average price over
+// the last TWAPWindow seconds instead of their spot price.
+type UniswapSymbol struct {
+	Routes     [][]UniswapPool `json:"routes"`
+	TWAPWindow uint32          `json:"twap_window"`
+}
+
+// UniswapConfig is the uniswap source config. RPCEndpoints are used in order,
+// failing over to the next one when the current one is unhealthy,
+// RPCEndpoint is kept for backwards compatibility and used first.
+type UniswapConfig struct {
+	RPCEndpoint  string                         `json:"rpc_endpoint"`
+	RPCEndpoints []string                       `json:"rpc_endpoints"`
+	Symbols      map[types.Symbol]UniswapSymbol `json:"symbols"`
+}
+
+// DefaultUniswapSymbols prices ETH against USDT and USDC, and VSG through ETH.
+var DefaultUniswapSymbols = map[types.Symbol]UniswapSymbol{
+	"ETHUSD": {Routes: [][]UniswapPool{
+		{ethUsdtPool},
+		{ethUsdcPool},
+	}},
+	"VSGUSD": {Routes: [][]UniswapPool{
+		{vsgEthPool, ethUsdtPool},
+		{vsgEthPool, ethUsdcPool},
+	}},
+}
+
+var (
+	ethUsdtPool = UniswapPool{Address: ethUsdtPairAddress, Version: UniswapV2, Token0Decimals: 18, Token1Decimals: 6}
+	ethUsdcPool = UniswapPool{Address: ethUsdcPairAddress, Version: UniswapV2, Token0Decimals: 6, Token1Decimals: 18, Inverse: true}
+	vsgEthPool  = UniswapPool{Address: vsgEthPairAddress, Version: UniswapV2, Token0Decimals: 18, Token1Decimals: 18}
+)
+
+var _ types.Source = (*UniswapSource)(nil)
+
+// UniswapSource is a Source pricing symbols through Uniswap pool routes.
+// It keeps a long-lived connection to one of the configured RPC endpoints,
+// failing over to the next one when it errors or lags behind, and queries
+// every pool in a single batched round-trip.
+type UniswapSource struct {
+	*TickSource
+	fetcher *uniswapFetcher
+}
+
+// NewUniswapSource instantiates a new UniswapSource instance, given the symbols and the source config.
+func NewUniswapSource(symbols set.Set[types.Symbol], sourceConfig json.RawMessage, logger zerolog.Logger) *UniswapSource {
+	fetcher := newUniswapFetcher(sourceConfig)
+	return &UniswapSource{
+		TickSource: NewTickSource(symbols, fetcher.fetchPrices, logger),
+		fetcher:    fetcher,
+	}
+}
+
+func (s *UniswapSource) Close() {
+	s.TickSource.Close()
+	s.fetcher.close()
+}
+
+// uniswapFetcher owns the RPC client and the TWAP samples across fetches.
+type uniswapFetcher struct {
+	config    *UniswapConfig
+	configErr error
+	samples   *uniswapV2Samples
+	pairABI   abi.ABI
+	poolABI   abi.ABI
+	endpoint  int // index of the endpoint in use
+	client    *rpc.Client
+}
+
+func newUniswapFetcher(sourceConfig json.RawMessage) *uniswapFetcher {
+	c, err := extractUniswapConfig(sourceConfig)
+	f := &uniswapFetcher{
+		config:    c,
+		configErr: err,
+		pairABI:   mustParseABI(uniswapPairABIJSON),
+		poolABI:   mustParseABI(uniswapPoolABIJSON),
+	}
+	if err == nil {
+		f.samples = newUniswapV2Samples(c.Symbols, uniswapV2SampleHistory)
+	}
+	return f
+}
+
+var _ types.FetchPricesFunc = (*uniswapFetcher)(nil).fetchPrices
+
+// fetchPrices returns the prices for given symbols or an error.
+func (f *uniswapFetcher) fetchPrices(symbols set.Set[types.Symbol], logger zerolog.Logger) (map[types.Symbol]types.RawPrice, error) {
+	if f.configErr != nil {
+		logger.Err(f.configErr).Msg("failed to extract uniswap config")
 		metrics.PriceSourceCounter.WithLabelValues(Uniswap, "false").Inc()
-		return nil, err
+		return nil, f.configErr
+	}
+
+	uniswapSymbols := make(map[types.Symbol]UniswapSymbol, len(symbols))
+	for symbol := range symbols {
+		uniswapSymbol, ok := f.config.Symbols[symbol]
+		if !ok {
+			logger.Warn().Str("symbol", string(symbol)).Msg("no uniswap routes configured for symbol")
+			continue
+		}
+		uniswapSymbols[symbol] = uniswapSymbol
 	}
 
-	parsedABI, err := abi.JSON(strings.NewReader(uniswapPairABIJSON))
+	pools, err := f.fetchPools(uniswapSymbols, logger)
 	if err != nil {
-		logger.Err(err).Msg("failed to parse contract ABI")
+		logger.Err(err).Msg("failed to fetch uniswap pools")
 		metrics.PriceSourceCounter.WithLabelValues(Uniswap, "false").Inc()
 		return nil, err
 	}
 
-	rawPrices = make(map[types.Symbol]float64)
+	rawPrices := make(map[types.Symbol]types.RawPrice)
+	for symbol, uniswapSymbol := range uniswapSymbols {
+		price, err := uniswapSymbol.price(pools)
+		if err != nil {
+			logger.Err(err).Msg(fmt.Sprintf("failed to fetch price for %s on data source %s", symbol, Uniswap))
+			continue
+		}
+
+		rawPrices[symbol] = types.RawPrice{Price: price}
+		logger.Debug().Msg(fmt.Sprintf("fetched price for %s on data source %s: %s", symbol, Uniswap, price))
+	}
+
+	if len(rawPrices) == 0 && len(symbols) > 0 {
+		metrics.PriceSourceCounter.WithLabelValues(Uniswap, "false").Inc()
+		return nil, fmt.Errorf("failed to fetch any price from %s", Uniswap)
+	}
+
+	metrics.PriceSourceCounter.WithLabelValues(Uniswap, "true").Inc()
+	return rawPrices, nil
+}
 
-	// Get price for ETH/USDT pair
-	ethPriceInUSDT, err := getPrice(client, parsedABI, ethUsdtPairAddress, 18, 6, false, logger)
+// fetchPools prices every pool of the symbols' routes with a single batch of calls,
+// pinned to the latest block of a healthy endpoint.
+func (f *uniswapFetcher) fetchPools(symbols map[types.Symbol]UniswapSymbol, logger zerolog.Logger) (*uniswapPools, error) {
+	block, err := f.latestBlock(logger)
 	if err != nil {
-		logger.Err(err).Msg("failed to fetch price for ETH/USDT")
 		return nil, err
-	} else {
-		logger.Debug().Msg(fmt.Sprintf("fetched price for ETH/USDT: %f", ethPriceInUSDT))
 	}
 
-	// Get price for ETH/USDC pair
-	ethPriceInUSDC, err := getPrice(client, parsedABI, ethUsdcPairAddress, 18, 6, true, logger)
+	pools := &uniswapPools{
+		prices: map[uniswapPoolPrice]*big.Float{},
+		errors: map[uniswapPoolPrice]error{},
+	}
+	calls := map[uniswapPoolPrice][]*uniswapCall{}
+	var batch []rpc.BatchElem
+	for _, symbol := range symbols {
+		for _, route := range symbol.Routes {
+			for _, pool := range route {
+				key := uniswapPoolPrice{pool: pool, twapWindow: symbol.TWAPWindow}
+				if _, ok := calls[key]; ok {
+					continue
+				}
+				calls[key] = f.poolCalls(key)
+				for _, call := range calls[key] {
+					elem, err := call.batchElem(pool.Address, block.Number)
+					if err != nil {
+						return nil, err
+					}
+					call.index = len(batch)
+					batch = append(batch, elem)
+				}
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), UniswapRequestTimeout)
+		defer cancel()
+		if err := f.client.BatchCallContext(ctx, batch); err != nil {
+			f.failover(err, logger)
+			return nil, fmt.Errorf("failed to call contracts: %w", err)
+		}
+	}
+
+	for key, poolCalls := range calls {
+		for _, call := range poolCalls {
+			call.err = batch[call.index].Error
+		}
+		price, err := f.poolPrice(key, poolCalls, block)
+		if err != nil {
+			pools.errors[key] = fmt.Errorf("pool %s: %w", key.pool.Address, err)
+			continue
+		}
+		pools.prices[key] = price
+	}
+	return pools, nil
+}
+
+// uniswapBlock is the subset of a block relevant to the uniswap source.
+type uniswapBlock struct {
+	Number *hexutil.Big   `json:"number"`
+	Time   hexutil.Uint64 `json:"timestamp"`
+}
+
+// latestBlock returns the latest block of the first healthy endpoint, starting from the one in use.
+// An endpoint is healthy when it answers and its latest block is not older than UniswapMaxBlockAge.
+func (f *uniswapFetcher) latestBlock(logger zerolog.Logger) (*uniswapBlock, error) {
+	var err error
+	for range f.config.RPCEndpoints {
+		var block *uniswapBlock
+		block, err = f.tryLatestBlock()
+		if err == nil {
+			return block, nil
+		}
+		f.failover(err, logger)
+	}
+	return nil, fmt.Errorf("no healthy rpc endpoint: %w", err)
+}
+
+func (f *uniswapFetcher) tryLatestBlock() (*uniswapBlock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), UniswapRequestTimeout)
+	defer cancel()
+
+	if f.client == nil {
+		client, err := rpc.DialContext(ctx, f.config.RPCEndpoints[f.endpoint])
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the Ethereum client: %w", err)
+		}
+		f.client = client
+	}
+
+	block := new(uniswapBlock)
+	if err := f.client.CallContext(ctx, block, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block: %w", err)
+	}
+	if block.Number == nil {
+		return nil, fmt.Errorf("latest block not found")
+	}
+	if age := time.Since(time.Unix(int64(block.Time), 0)); age > UniswapMaxBlockAge {
+		return nil, fmt.Errorf("latest block %s is %s old", block.Number.String(), age.Truncate(time.Second))
+	}
+	return block, nil
+}
+
+// failover closes the client of the unhealthy endpoint in use and moves to the next endpoint.
+func (f *uniswapFetcher) failover(err error, logger zerolog.Logger) {
+	unhealthy := f.config.RPCEndpoints[f.endpoint]
+	f.close()
+	f.endpoint = (f.endpoint + 1) % len(f.config.RPCEndpoints)
+	logger.Warn().Err(err).
+		Str("unhealthy", unhealthy).
+		Str("endpoint", f.config.RPCEndpoints[f.endpoint]).
+		Msg("uniswap rpc endpoint unhealthy, failing over")
+}
+
+func (f *uniswapFetcher) close() {
+	if f.client != nil {
+		f.client.Close()
+		f.client = nil
+	}
+}
+
+// uniswapCall is a contract call of a batch.
+type uniswapCall struct {
+	contractABI abi.ABI
+	method      string
+	args        []interface{}
+	index       int // index of the call in the batch
+	result      hexutil.Bytes
+	err         error
+}
+
+func (c *uniswapCall) batchElem(address string, blockNumber *hexutil.Big) (rpc.BatchElem, error) {
+	callData, err := c.contractABI.Pack(c.method, c.args...)
 	if err != nil {
-		logger.Err(err).Msg("failed to fetch price for ETH/USDC")
-		return nil, err
-	} else {
-		logger.Debug().Msg(fmt.Sprintf("fetched price for ETH/USDC: %f", ethPriceInUSDC))
+		return rpc.BatchElem{}, fmt.Errorf("failed to pack call data: %v", err)
 	}
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []interface{}{
+			map[string]interface{}{"to": common.HexToAddress(address), "data": hexutil.Bytes(callData)},
+			blockNumber,
+		},
+		Result: &c.result,
+	}, nil
+}
 
-	// Calculate average ETH price in USD
-	// if price diverts too much, return an error
-	if ethPriceInUSDT/ethPriceInUSDC > 1.25 || ethPriceInUSDT/ethPriceInUSDC < 0.8 {
-		logger.Err(fmt.Errorf("price deviation too high: %f/%f", ethPriceInUSDT, ethPriceInUSDC)).Msg("price deviation too high")
-		return nil, fmt.Errorf("price deviation too high: %f/%f", ethPriceInUSDT, ethPriceInUSDC)
+func (c *uniswapCall) unpack(out interface{}) error {
+	if c.err != nil {
+		return fmt.Errorf("failed to call contract: %v", c.err)
+	}
+	if err := c.contractABI.UnpackIntoInterface(out, c.method, c.result); err != nil {
+		return fmt.Errorf("failed to unpack result: %v", err)
 	}
+	return nil
+}
 
-	ethPriceInUSD := (ethPriceInUSDT + ethPriceInUSDC) / 2
-	rawPrices["ETHUSD"] = ethPriceInUSD
+// poolCalls returns the calls needed to price the pool.
+func (f *uniswapFetcher) poolCalls(key uniswapPoolPrice) []*uniswapCall {
+	switch {
+	case key.pool.Version == UniswapV3 && key.twapWindow > 0:
+		return []*uniswapCall{{contractABI: f.poolABI, method: "observe", args: []interface{}{[]uint32{key.twapWindow, 0}}}}
+	case key.pool.Version == UniswapV3:
+		return []*uniswapCall{{contractABI: f.poolABI, method: "slot0"}}
+	case key.twapWindow > 0:
+		return []*uniswapCall{
+			{contractABI: f.pairABI, method: "getReserves"},
+			{contractABI: f.pairABI, method: "price0CumulativeLast"},
+			{contractABI: f.pairABI, method: "price1CumulativeLast"},
+		}
+	default:
+		return []*uniswapCall{{contractABI: f.pairABI, method: "getReserves"}}
+	}
+}
 
-	// Get price for VSG/ETH pair
-	vsgPriceInETH, err := getPrice(client, parsedABI, vsgEthPairAddress, 18, 18, false, logger)
+// poolPrice returns the price of the pool from the results of its calls.
+func (f *uniswapFetcher) poolPrice(key uniswapPoolPrice, calls []*uniswapCall, block *uniswapBlock) (*big.Float, error) {
+	var ratio *big.Float
+	var err error
+	switch {
+	case key.pool.Version == UniswapV3 && key.twapWindow > 0:
+		ratio, err = observeRatio(calls[0], key.twapWindow)
+	case key.pool.Version == UniswapV3:
+		ratio, err = slot0Ratio(calls[0])
+	case key.twapWindow > 0:
+		ratio, err = f.cumulativeRatio(key, calls, block)
+	default:
+		ratio, err = reservesRatio(calls[0])
+	}
 	if err != nil {
-		logger.Err(err).Msg("failed to fetch price for VSG/ETH")
 		return nil, err
-	} else {
-		logger.Debug().Msg(fmt.Sprintf("fetched price for VSG/ETH: %f", vsgPriceInETH))
 	}
 
-	// Calculate VSG price in USD
-	vsgPriceInUSD := vsgPriceInETH * ethPriceInUSD
-	rawPrices["VSGUSD"] = vsgPriceInUSD
+	// Adjust for token decimals, ratio is token1 units per token0 unit
+	decimals0 := new(big.Float).SetInt(big.NewInt(0).Exp(big.NewInt(10), big.NewInt(key.pool.Token0Decimals), nil))
+	decimals1 := new(big.Float).SetInt(big.NewInt(0).Exp(big.NewInt(10), big.NewInt(key.pool.Token1Decimals), nil))
+	price := new(big.Float).SetPrec(uniswapPricePrecision).Mul(ratio, decimals0)
+	price.Quo(price, decimals1)
+	if key.pool.Inverse {
+		price.Quo(big.NewFloat(1), price)
+	}
+	return price, nil
+}
 
-	metrics.PriceSourceCounter.WithLabelValues(Uniswap, "true").Inc()
-	return rawPrices, nil
+type uniswapReserves struct {
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast uint32
 }
 
-func getPrice(client *ethclient.Client, parsedABI abi.ABI, pairAddress string, token0Decimals, token1Decimals int64, reverse bool, logger zerolog.Logger) (float64, error) {
-	addr := common.HexToAddress(pairAddress)
-	callData, err := parsedABI.Pack("getReserves")
-	if err != nil {
-		return 0, fmt.Errorf("failed to pack call data: %v", err)
+// reservesRatio returns reserve1/reserve0 of a Uniswap V2 pair.
+func reservesRatio(call *uniswapCall) (*big.Float, error) {
+	var reserves uniswapReserves
+	if err := call.unpack(&reserves); err != nil {
+		return nil, err
 	}
+	if reserves.Reserve0.Sign() == 0 || reserves.Reserve1.Sign() == 0 {
+		return nil, fmt.Errorf("one of the reserves is zero")
+	}
+	return new(big.Float).SetPrec(uniswapPricePrecision).Quo(new(big.Float).SetInt(reserves.Reserve1), new(big.Float).SetInt(reserves.Reserve0)), nil
+}
+
+// slot0Ratio returns (sqrtPriceX96 / 2^96)^2 of a Uniswap V3 pool.
+func slot0Ratio(call *uniswapCall) (*big.Float, error) {
+	var slot0 struct {
+		SqrtPriceX96               *big.Int
+		Tick                       *big.Int
+		ObservationIndex           uint16
+		ObservationCardinality     uint16
+		ObservationCardinalityNext uint16
+		FeeProtocol                uint8
+		Unlocked                   bool
+	}
+	if err := call.unpack(&slot0); err != nil {
+		return nil, err
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		return nil, fmt.Errorf("pool is not initialized")
+	}
+	return sqrtPriceX96ToRatio(slot0.SqrtPriceX96), nil
+}
+
+// observeRatio returns 1.0001^averageTick of a Uniswap V3 pool over the last twapWindow seconds.
+func observeRatio(call *uniswapCall, twapWindow uint32) (*big.Float, error) {
+	var observations struct {
+		TickCumulatives                    []*big.Int
+		SecondsPerLiquidityCumulativeX128s []*big.Int
+	}
+	if err := call.unpack(&observations); err != nil {
+		return nil, err
+	}
+	if len(observations.TickCumulatives) != 2 {
+		return nil, fmt.Errorf("unexpected number of observations: %d", len(observations.TickCumulatives))
+	}
+
+	averageTick := averageTick(observations.TickCumulatives[0], observations.TickCumulatives[1], twapWindow)
+	return tickRatio(averageTick), nil
+}
 
-	msg := ethereum.CallMsg{
-		To:   &addr,
-		Data: callData,
+// tickRatio returns 1.0001^tick, computed by exponentiation by squaring
+// at uniswapPricePrecision so that the ratio is exact to far more than 18 decimals.
+func tickRatio(tick int64) *big.Float {
+	base, _ := new(big.Float).SetPrec(uniswapPricePrecision).SetString("1.0001")
+	ratio := new(big.Float).SetPrec(uniswapPricePrecision).SetInt64(1)
+	exponent := uint64(tick)
+	if tick < 0 {
+		exponent = uint64(-tick)
+	}
+	for ; exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			ratio.Mul(ratio, base)
+		}
+		base.Mul(base, base)
+	}
+	if tick < 0 {
+		ratio.Quo(new(big.Float).SetPrec(uniswapPricePrecision).SetInt64(1), ratio)
+	}
+	return ratio
+}
+
+// cumulativeRatio returns the time weighted average of reserve1/reserve0 of a Uniswap V2 pair
+// over the last twapWindow seconds, computed from the cumulative prices sampled on previous fetches.
+// When the pool is inverted, the average of reserve0/reserve1 is used, so that inverting the
+// returned ratio yields the time weighted average price of token1.
+func (f *uniswapFetcher) cumulativeRatio(key uniswapPoolPrice, calls []*uniswapCall, block *uniswapBlock) (*big.Float, error) {
+	var reserves uniswapReserves
+	if err := calls[0].unpack(&reserves); err != nil {
+		return nil, err
+	}
+	if reserves.Reserve0.Sign() == 0 || reserves.Reserve1.Sign() == 0 {
+		return nil, fmt.Errorf("one of the reserves is zero")
 	}
 
-	result, err := client.CallContract(context.Background(), msg, nil)
+	sample := uniswapV2Sample{timestamp: uint64(block.Time)}
+	if err := calls[1].unpack(&sample.price0Cumulative); err != nil {
+		return nil, err
+	}
+	if err := calls[2].unpack(&sample.price1Cumulative); err != nil {
+		return nil, err
+	}
+
+	// the cumulative prices are only updated on the first trade of a block,
+	// accumulate the current prices up to the latest block as the pair would.
+	elapsed := big.NewInt(int64(uint32(block.Time) - reserves.BlockTimestampLast))
+	sample.price0Cumulative = new(big.Int).Add(sample.price0Cumulative, uqPrice(reserves.Reserve1, reserves.Reserve0, elapsed))
+	sample.price1Cumulative = new(big.Int).Add(sample.price1Cumulative, uqPrice(reserves.Reserve0, reserves.Reserve1, elapsed))
+
+	average0, average1, err := f.samples.average(common.HexToAddress(key.pool.Address), sample, key.twapWindow)
 	if err != nil {
-		return 0, fmt.Errorf("failed to call contract: %v", err)
+		return nil, err
+	}
+	if key.pool.Inverse {
+		return new(big.Float).Quo(big.NewFloat(1), average1), nil
+	}
+	return average0, nil
+}
+
+// ValidateUniswapConfig returns an error if the uniswap source config is invalid or a pool is misconfigured.
+func ValidateUniswapConfig(jsonConfig json.RawMessage) error {
+	_, err := extractUniswapConfig(jsonConfig)
+	return err
+}
+
+// extractUniswapConfig returns the config, falling back to the public node and default symbols
+// when they are not provided, or an error if a pool is misconfigured.
+func extractUniswapConfig(jsonConfig json.RawMessage) (*UniswapConfig, error) {
+	c := &UniswapConfig{}
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal(jsonConfig, c); err != nil {
+			return nil, fmt.Errorf("invalid uniswap config: %w", err)
+		}
+	}
+	if c.RPCEndpoint != "" {
+		c.RPCEndpoints = append([]string{c.RPCEndpoint}, c.RPCEndpoints...)
+		c.RPCEndpoint = ""
+	}
+	if len(c.RPCEndpoints) == 0 {
+		c.RPCEndpoints = []string{publicNodeURL}
+	}
+	if len(c.Symbols) == 0 {
+		c.Symbols = DefaultUniswapSymbols
+	}
+
+	for symbol, uniswapSymbol := range c.Symbols {
+		if len(uniswapSymbol.Routes) == 0 {
+			return nil, fmt.Errorf("invalid uniswap config: no routes for %s", symbol)
+		}
+		for _, route := range uniswapSymbol.Routes {
+			if len(route) == 0 {
+				return nil, fmt.Errorf("invalid uniswap config: empty route for %s", symbol)
+			}
+			for i := range route {
+				if err := route[i].validate(); err != nil {
+					return nil, fmt.Errorf("invalid uniswap config: %s: %w", symbol, err)
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
+func (p *UniswapPool) validate() error {
+	if !common.IsHexAddress(p.Address) {
+		return fmt.Errorf("invalid pool address %q", p.Address)
 	}
+	switch p.Version {
+	case "":
+		p.Version = UniswapV2
+	case UniswapV2, UniswapV3:
+	default:
+		return fmt.Errorf("unknown version %q for pool %s", p.Version, p.Address)
+	}
+	if p.Token0Decimals < 0 || p.Token1Decimals < 0 {
+		return fmt.Errorf("negative decimals for pool %s", p.Address)
+	}
+	if p.Token0Decimals > uniswapMaxDecimals || p.Token1Decimals > uniswapMaxDecimals {
+		return fmt.Errorf("decimals above %d for pool %s", uniswapMaxDecimals, p.Address)
+	}
+	return nil
+}
+
+// price returns the average price of the routes, or an error if
+// a pool cannot be priced or the routes deviate too much.
+func (s UniswapSymbol) price(pools *uniswapPools) (sdkmath.LegacyDec, error) {
+	var minPrice, maxPrice *big.Float
+	sum := new(big.Float).SetPrec(uniswapPricePrecision)
+	for _, route := range s.Routes {
+		routePrice := new(big.Float).SetPrec(uniswapPricePrecision).SetInt64(1)
+		for _, pool := range route {
+			poolPrice, err := pools.price(pool, s.TWAPWindow)
+			if err != nil {
+				return sdkmath.LegacyDec{}, err
+			}
+			// zero and infinite prices would panic multiplying or dividing them by each other
+			if !isFinitePositive(poolPrice) {
+				return sdkmath.LegacyDec{}, fmt.Errorf("invalid price %s for pool %s", poolPrice.Text('g', 10), pool.Address)
+			}
+			routePrice.Mul(routePrice, poolPrice)
+		}
+		if !isFinitePositive(routePrice) {
+			return sdkmath.LegacyDec{}, fmt.Errorf("invalid route price %s", routePrice.Text('g', 10))
+		}
+
+		if minPrice == nil || routePrice.Cmp(minPrice) < 0 {
+			minPrice = routePrice
+		}
+		if maxPrice == nil || routePrice.Cmp(maxPrice) > 0 {
+			maxPrice = routePrice
+		}
+		sum.Add(sum, routePrice)
+	}
+
+	deviation, _ := new(big.Float).Quo(maxPrice, minPrice).Float64()
+	if deviation > uniswapMaxRouteDeviation {
+		return sdkmath.LegacyDec{}, fmt.Errorf("price deviation too high: %s/%s", maxPrice.Text('g', 10), minPrice.Text('g', 10))
+	}
+	average := sum.Quo(sum, new(big.Float).SetInt64(int64(len(s.Routes))))
+	return types.ParsePrice(average.Text('f', sdkmath.LegacyPrecision))
+}
+
+// isFinitePositive reports whether the price is neither zero, negative nor infinite.
+func isFinitePositive(price *big.Float) bool {
+	return price.Sign() > 0 && !price.IsInf()
+}
+
+// uniswapPoolPrice identifies the price of a pool over a TWAP window, 0 being the spot price.
+type uniswapPoolPrice struct {
+	pool       UniswapPool
+	twapWindow uint32
+}
 
-	var reserves struct {
-		Reserve0           *big.Int
-		Reserve1           *big.Int
-		BlockTimestampLast uint32
+// uniswapPools holds the prices of the pools fetched in a batch,
+// or the reason they could not be priced.
+type uniswapPools struct {
+	prices map[uniswapPoolPrice]*big.Float
+	errors map[uniswapPoolPrice]error
+}
+
+func (p *uniswapPools) price(pool UniswapPool, twapWindow uint32) (*big.Float, error) {
+	key := uniswapPoolPrice{pool: pool, twapWindow: twapWindow}
+	if err, ok := p.errors[key]; ok {
+		return nil, err
 	}
+	price, ok := p.prices[key]
+	if !ok {
+		return nil, fmt.Errorf("pool %s was not fetched", pool.Address)
+	}
+	return price, nil
+}
 
-	err = parsedABI.UnpackIntoInterface(&reserves, "getReserves", result)
+// sqrtPriceX96ToRatio converts a Q64.96 square root price into the raw token1/token0 ratio.
+func sqrtPriceX96ToRatio(sqrtPriceX96 *big.Int) *big.Float {
+	q96 := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96))
+	sqrtPrice := new(big.Float).SetPrec(uniswapPricePrecision).Quo(new(big.Float).SetInt(sqrtPriceX96), q96)
+	return new(big.Float).SetPrec(uniswapPricePrecision).Mul(sqrtPrice, sqrtPrice)
+}
+
+func mustParseABI(abiJSON string) abi.ABI {
+	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
 	if err != nil {
-		return 0, fmt.Errorf("failed to unpack result: %v", err)
+		panic(err)
 	}
+	return parsedABI
+}
 
-	reserve0 := new(big.Float).SetInt(reserves.Reserve0)
-	reserve1 := new(big.Float).SetInt(reserves.Reserve1)
-	logger.Debug().Msg(fmt.Sprintf("fetched reserves: %s, %s", reserve0.String(), reserve1.String()))
+// averageTick returns the average tick between two tick cumulatives, rounded towards negative infinity.
+func averageTick(tickCumulativeStart, tickCumulativeEnd *big.Int, twapWindow uint32) int64 {
+	delta := new(big.Int).Sub(tickCumulativeEnd, tickCumulativeStart)
+	// big.Int.Div rounds towards negative infinity for a positive divisor
+	return new(big.Int).Div(delta, big.NewInt(int64(twapWindow))).Int64()
+}
+
+// uqPrice returns numerator/denominator as a UQ112x112 multiplied by elapsed seconds.
+func uqPrice(numerator, denominator, elapsed *big.Int) *big.Int {
+	price := new(big.Int).Lsh(numerator, 112)
+	price.Div(price, denominator)
+	return price.Mul(price, elapsed)
+}
 
-	if reserve0.Cmp(big.NewFloat(0)) == 0 || reserve1.Cmp(big.NewFloat(0)) == 0 {
-		return 0, fmt.Errorf("one of the reserves is zero")
+// uniswapV2Sample is a snapshot of the cumulative prices of a Uniswap V2 pair.
+type uniswapV2Sample struct {
+	timestamp        uint64
+	price0Cumulative *big.Int
+	price1Cumulative *big.Int
+}
+
+// uniswapV2History keeps the cumulative prices sampled from Uniswap V2 pairs. It outlives
+// the uniswap source, which is restarted whenever its config changes, so that the pairs
+// which are still configured do not go through the warm-up of their TWAP window again.
+type uniswapV2History struct {
+	mu      sync.Mutex
+	samples map[common.Address][]uniswapV2Sample
+}
+
+func newUniswapV2History() *uniswapV2History {
+	return &uniswapV2History{samples: map[common.Address][]uniswapV2Sample{}}
+}
+
+// uniswapV2SampleHistory is the history shared by the successive uniswap sources.
+var uniswapV2SampleHistory = newUniswapV2History()
+
+// uniswapV2Samples records the samples of the configured Uniswap V2 pairs in a history,
+// from which their time weighted average prices are computed.
+type uniswapV2Samples struct {
+	windows map[common.Address]uint32 // longest TWAP window of each pair
+	history *uniswapV2History
+}
+
+// newUniswapV2Samples returns the samples of the pairs used by the given symbols,
+// dropping from the history the pairs which are no longer used.
+func newUniswapV2Samples(symbols map[types.Symbol]UniswapSymbol, history *uniswapV2History) *uniswapV2Samples {
+	s := &uniswapV2Samples{
+		windows: map[common.Address]uint32{},
+		history: history,
+	}
+	for _, symbol := range symbols {
+		for _, route := range symbol.Routes {
+			for _, pool := range route {
+				address := common.HexToAddress(pool.Address)
+				if symbol.TWAPWindow > s.windows[address] {
+					s.windows[address] = symbol.TWAPWindow
+				}
+			}
+		}
 	}
 
-	// Adjust for token decimals
-	decimals0 := new(big.Float).SetInt(big.NewInt(0).Exp(big.NewInt(10), big.NewInt(token0Decimals), nil))
-	decimals1 := new(big.Float).SetInt(big.NewInt(0).Exp(big.NewInt(10), big.NewInt(token1Decimals), nil))
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	for address := range history.samples {
+		if _, ok := s.windows[address]; !ok {
+			delete(history.samples, address)
+		}
+	}
+	return s
+}
+
+// average records the sample and returns the average prices of token0 and token1 between it
+// and the most recent sample at least twapWindow seconds older, or an error if there is none yet.
+func (s *uniswapV2Samples) average(address common.Address, sample uniswapV2Sample, twapWindow uint32) (*big.Float, *big.Float, error) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
 
-	var price *big.Float
+	samples := s.history.samples[address]
+	if len(samples) == 0 || samples[len(samples)-1].timestamp < sample.timestamp {
+		samples = append(samples, sample)
+	}
 
-	if reverse {
-		price = new(big.Float).Quo(reserve0, reserve1)
-		price.Quo(price, decimals1)
-		price.Mul(price, decimals0)
-	} else {
-		price = new(big.Float).Quo(reserve1, reserve0)
-		price.Quo(price, decimals1)
-		price.Mul(price, decimals0)
+	// only keep the most recent sample older than the longest window, and the ones after it
+	for len(samples) > 1 && sample.timestamp-samples[1].timestamp >= uint64(s.windows[address]) {
+		samples = samples[1:]
 	}
+	s.history.samples[address] = samples
 
-	priceFloat, _ := price.Float64()
+	var start *uniswapV2Sample
+	for i := len(samples) - 1; i >= 0; i-- {
+		if sample.timestamp-samples[i].timestamp >= uint64(twapWindow) {
+			start = &samples[i]
+			break
+		}
+	}
+	if start == nil {
+		return nil, nil, fmt.Errorf("not enough samples for a %ds twap window, sampled %ds", twapWindow, sample.timestamp-samples[0].timestamp)
+	}
+
+	elapsed := new(big.Float).SetUint64(sample.timestamp - start.timestamp)
+	return cumulativeAverage(start.price0Cumulative, sample.price0Cumulative, elapsed),
+		cumulativeAverage(start.price1Cumulative, sample.price1Cumulative, elapsed),
+		nil
+}
 
-	return priceFloat, nil
+// cumulativeAverage returns the average UQ112x112 price between two cumulative prices,
+// which overflow by design, hence the difference is taken modulo 2^256.
+func cumulativeAverage(start, end *big.Int, elapsed *big.Float) *big.Float {
+	delta := new(big.Int).Sub(end, start)
+	delta.Mod(delta, new(big.Int).Lsh(big.NewInt(1), 256))
+	average := new(big.Float).SetInt(delta)
+	average.Quo(average, elapsed)
+	return average.Quo(average, new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 112)))
 }
@@ -1,23 +1,388 @@
 package sources
 
 import (
-	"fmt"
+	"encoding/json"
 	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	sdkmath "cosmossdk.io/math"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/vsc-blockchain/core/x/common/set"
 	"github.com/vsc-blockchain/pricefeeder/types"
 )
 
-func TestUniswapPriceUpdate(t *testing.T) {
+const (
+	testV2Pool     = "0x0000000000000000000000000000000000000001"
+	testV3Pool     = "0x0000000000000000000000000000000000000002"
+	testV3TWAPPool = "0x0000000000000000000000000000000000000004"
+)
+
+// ethRPCServer is a local JSON-RPC server answering eth_call with the given ABI encoded
+// results, by contract address, and eth_getBlockByNumber with a block of the given time.
+type ethRPCServer struct {
+	url       string
+	blockTime time.Time
+	results   map[string][]byte
+	requests  int32 // number of http requests received
+}
+
+func newEthRPCServer(t *testing.T, results map[string][]byte) *ethRPCServer {
+	s := &ethRPCServer{blockTime: time.Now(), results: results}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if body[0] != '[' {
+			require.NoError(t, json.NewEncoder(w).Encode(s.handle(t, body)))
+			return
+		}
+		var batch []json.RawMessage
+		require.NoError(t, json.Unmarshal(body, &batch))
+		responses := make([]interface{}, len(batch))
+		for i, req := range batch {
+			responses[i] = s.handle(t, req)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(responses))
+	}))
+	t.Cleanup(server.Close)
+	s.url = server.URL
+	return s
+}
+
+func (s *ethRPCServer) handle(t *testing.T, body []byte) map[string]interface{} {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(body, &req))
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_getBlockByNumber":
+		resp["result"] = map[string]interface{}{
+			"number":    "0x10",
+			"timestamp": hexutil.EncodeUint64(uint64(s.blockTime.Unix())),
+		}
+	case "eth_call":
+		var call struct {
+			To string `json:"to"`
+		}
+		require.NoError(t, json.Unmarshal(req.Params[0], &call))
+		result, ok := s.results[strings.ToLower(call.To)]
+		if ok {
+			resp["result"] = hexutil.Encode(result)
+		} else {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "execution reverted"}
+		}
+	default:
+		t.Fatalf("unexpected method %s", req.Method)
+	}
+	return resp
+}
+
+func getReservesResult(t *testing.T, reserve0, reserve1 *big.Int) []byte {
+	b, err := mustParseABI(uniswapPairABIJSON).Methods["getReserves"].Outputs.Pack(reserve0, reserve1, uint32(0))
+	require.NoError(t, err)
+	return b
+}
+
+func slot0Result(t *testing.T, sqrtPriceX96 *big.Int) []byte {
+	b, err := mustParseABI(uniswapPoolABIJSON).Methods["slot0"].Outputs.Pack(
+		sqrtPriceX96, big.NewInt(0), uint16(0), uint16(0), uint16(0), uint8(0), true)
+	require.NoError(t, err)
+	return b
+}
+
+func observeResult(t *testing.T, tickCumulativeStart, tickCumulativeEnd int64) []byte {
+	b, err := mustParseABI(uniswapPoolABIJSON).Methods["observe"].Outputs.Pack(
+		[]*big.Int{big.NewInt(tickCumulativeStart), big.NewInt(tickCumulativeEnd)},
+		[]*big.Int{big.NewInt(0), big.NewInt(0)})
+	require.NoError(t, err)
+	return b
+}
+
+func TestUniswapFetcher(t *testing.T) {
+	// 1 token0 (18 decimals) for 2000 token1 (6 decimals)
+	reserve0, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	reserve1 := big.NewInt(2_000_000_000_000)
+	// sqrt(4) * 2^96, 1 token0 for 4 token1, both 18 decimals
+	sqrtPriceX96 := new(big.Int).Lsh(big.NewInt(2), 96)
+
+	server := newEthRPCServer(t, map[string][]byte{
+		testV2Pool: getReservesResult(t, reserve0, reserve1),
+		testV3Pool: slot0Result(t, sqrtPriceX96),
+		// average tick of 6932 over 600s, 1.0001^6932 ~= 2
+		testV3TWAPPool: observeResult(t, 1_000_000, 1_000_000+6932*600),
+	})
+
+	config := UniswapConfig{
+		RPCEndpoint: server.url,
+		Symbols: map[types.Symbol]UniswapSymbol{
+			"ETHUSD": {Routes: [][]UniswapPool{
+				{{Address: testV2Pool, Token0Decimals: 18, Token1Decimals: 6}},
+			}},
+			"TOKENUSD": {Routes: [][]UniswapPool{
+				{
+					{Address: testV3Pool, Version: UniswapV3, Token0Decimals: 18, Token1Decimals: 18, Inverse: true},
+					{Address: testV2Pool, Token0Decimals: 18, Token1Decimals: 6},
+				},
+			}},
+			"TWAPUSD": {TWAPWindow: 600, Routes: [][]UniswapPool{
+				{{Address: testV3TWAPPool, Version: UniswapV3, Token0Decimals: 18, Token1Decimals: 18}},
+			}},
+		},
+	}
+	rawConfig, err := json.Marshal(config)
+	require.NoError(t, err)
+
 	t.Run("success", func(t *testing.T) {
-		rawPrices, err := UniswapPriceUpdate(set.New[types.Symbol]("VSGUSD"), zerolog.New(io.Discard))
+		fetcher := newUniswapFetcher(rawConfig)
+		defer fetcher.close()
+
+		requests := atomic.LoadInt32(&server.requests)
+		rawPrices, err := fetcher.fetchPrices(set.New[types.Symbol]("ETHUSD", "TOKENUSD", "TWAPUSD"), zerolog.New(io.Discard))
+		require.NoError(t, err)
+		// the latest block, then every pool in a single batch
+		require.Equal(t, requests+2, atomic.LoadInt32(&server.requests))
+		require.Len(t, rawPrices, 3)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("2000").Equal(rawPrices["ETHUSD"].Price), "got %s", rawPrices["ETHUSD"].Price)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("500").Equal(rawPrices["TOKENUSD"].Price), "got %s", rawPrices["TOKENUSD"].Price)
+		require.InDelta(t, 2, rawPrices["TWAPUSD"].Price.MustFloat64(), 1e-3)
+	})
+
+	t.Run("unconfigured symbol", func(t *testing.T) {
+		fetcher := newUniswapFetcher(rawConfig)
+		defer fetcher.close()
+
+		rawPrices, err := fetcher.fetchPrices(set.New[types.Symbol]("ETHUSD", "BTCUSD"), zerolog.New(io.Discard))
+		require.NoError(t, err)
+		require.Len(t, rawPrices, 1)
+		require.Contains(t, rawPrices, types.Symbol("ETHUSD"))
+	})
+
+	t.Run("failing pool", func(t *testing.T) {
+		c := UniswapConfig{
+			RPCEndpoint: server.url,
+			Symbols: map[types.Symbol]UniswapSymbol{
+				"ETHUSD": {Routes: [][]UniswapPool{{{Address: common.Address{0x3}.Hex()}}}},
+			},
+		}
+		rawConfig, err := json.Marshal(c)
+		require.NoError(t, err)
+
+		fetcher := newUniswapFetcher(rawConfig)
+		defer fetcher.close()
+
+		_, err = fetcher.fetchPrices(set.New[types.Symbol]("ETHUSD"), zerolog.New(io.Discard))
+		require.Error(t, err)
+	})
+
+	t.Run("failover", func(t *testing.T) {
+		lagging := newEthRPCServer(t, nil)
+		lagging.blockTime = time.Now().Add(-time.Hour)
+		c := UniswapConfig{
+			RPCEndpoints: []string{"http://127.0.0.1:1", lagging.url, server.url},
+			Symbols:      config.Symbols,
+		}
+		rawConfig, err := json.Marshal(c)
+		require.NoError(t, err)
+
+		fetcher := newUniswapFetcher(rawConfig)
+		defer fetcher.close()
+
+		rawPrices, err := fetcher.fetchPrices(set.New[types.Symbol]("ETHUSD"), zerolog.New(io.Discard))
+		require.NoError(t, err)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr("2000").Equal(rawPrices["ETHUSD"].Price), "got %s", rawPrices["ETHUSD"].Price)
+		require.Equal(t, 2, fetcher.endpoint)
+
+		// the healthy endpoint is kept
+		_, err = fetcher.fetchPrices(set.New[types.Symbol]("ETHUSD"), zerolog.New(io.Discard))
+		require.NoError(t, err)
+		require.Equal(t, 2, fetcher.endpoint)
+	})
+
+	t.Run("no healthy endpoint", func(t *testing.T) {
+		c := UniswapConfig{RPCEndpoints: []string{"http://127.0.0.1:1"}, Symbols: config.Symbols}
+		rawConfig, err := json.Marshal(c)
+		require.NoError(t, err)
+
+		fetcher := newUniswapFetcher(rawConfig)
+		defer fetcher.close()
+
+		_, err = fetcher.fetchPrices(set.New[types.Symbol]("ETHUSD"), zerolog.New(io.Discard))
+		require.ErrorContains(t, err, "no healthy rpc endpoint")
+	})
+}
+
+func TestUniswapSource_Close(t *testing.T) {
+	s := NewUniswapSource(set.New[types.Symbol]("ETHUSD"), nil, zerolog.New(io.Discard))
+	s.Close()
+	require.Nil(t, s.fetcher.client)
+}
+
+func TestExtractUniswapConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c, err := extractUniswapConfig(nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{publicNodeURL}, c.RPCEndpoints)
+		require.Equal(t, DefaultUniswapSymbols, c.Symbols)
+	})
+
+	t.Run("rpc endpoint first", func(t *testing.T) {
+		c, err := extractUniswapConfig(json.RawMessage(`{"rpc_endpoint": "http://a", "rpc_endpoints": ["http://b"]}`))
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://a", "http://b"}, c.RPCEndpoints)
+	})
+
+	t.Run("defaults version", func(t *testing.T) {
+		c, err := extractUniswapConfig(json.RawMessage(`{"symbols": {"ETHUSD": {"routes": [[{"address": "` + testV2Pool + `"}]]}}}`))
+		require.NoError(t, err)
+		require.Equal(t, UniswapV2, c.Symbols["ETHUSD"].Routes[0][0].Version)
+	})
+
+	for name, config := range map[string]string{
+		"invalid json":      `{`,
+		"no routes":         `{"symbols": {"ETHUSD": {"routes": []}}}`,
+		"empty route":       `{"symbols": {"ETHUSD": {"routes": [[]]}}}`,
+		"invalid address":   `{"symbols": {"ETHUSD": {"routes": [[{"address": "0x12"}]]}}}`,
+		"unknown version":   `{"symbols": {"ETHUSD": {"routes": [[{"address": "` + testV2Pool + `", "version": "v4"}]]}}}`,
+		"negative decimals": `{"symbols": {"ETHUSD": {"routes": [[{"address": "` + testV2Pool + `", "token0_decimals": -1}]]}}}`,
+		"too many decimals": `{"symbols": {"ETHUSD": {"routes": [[{"address": "` + testV2Pool + `", "token1_decimals": 78}]]}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := extractUniswapConfig(json.RawMessage(config))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestUniswapSymbol_RouteDeviation(t *testing.T) {
+	pools := &uniswapPools{prices: map[uniswapPoolPrice]*big.Float{
+		{pool: UniswapPool{Address: testV2Pool}}: big.NewFloat(100),
+		{pool: UniswapPool{Address: testV3Pool}}: big.NewFloat(200),
+	}}
+
+	_, err := UniswapSymbol{Routes: [][]UniswapPool{{{Address: testV2Pool}}, {{Address: testV3Pool}}}}.price(pools)
+	require.ErrorContains(t, err, "price deviation too high")
+
+	price, err := UniswapSymbol{Routes: [][]UniswapPool{{{Address: testV2Pool}}, {{Address: testV2Pool}}}}.price(pools)
+	require.NoError(t, err)
+	require.True(t, sdkmath.LegacyMustNewDecFromStr("100").Equal(price), "got %s", price)
+}
+
+func TestUniswapSymbol_InvalidPrice(t *testing.T) {
+	for name, invalid := range map[string]*big.Float{
+		"zero":     big.NewFloat(0),
+		"infinite": new(big.Float).SetInf(false),
+	} {
+		t.Run(name, func(t *testing.T) {
+			pools := &uniswapPools{prices: map[uniswapPoolPrice]*big.Float{
+				{pool: UniswapPool{Address: testV2Pool}}: big.NewFloat(100),
+				{pool: UniswapPool{Address: testV3Pool}}: invalid,
+			}}
+
+			// the routes are rejected instead of dividing by zero or infinity
+			_, err := UniswapSymbol{Routes: [][]UniswapPool{{{Address: testV2Pool}}, {{Address: testV3Pool}}}}.price(pools)
+			require.ErrorContains(t, err, "invalid price")
+			_, err = UniswapSymbol{Routes: [][]UniswapPool{{{Address: testV3Pool}, {Address: testV2Pool}}}}.price(pools)
+			require.ErrorContains(t, err, "invalid price")
+		})
+	}
+}
+
+func TestUniswapV2Samples(t *testing.T) {
+	address := common.HexToAddress(testV2Pool)
+	symbols := map[types.Symbol]UniswapSymbol{
+		"ETHUSD": {TWAPWindow: 60, Routes: [][]UniswapPool{{{Address: testV2Pool}}}},
+	}
+	history := newUniswapV2History()
+	samples := newUniswapV2Samples(symbols, history)
+	q112 := new(big.Int).Lsh(big.NewInt(1), 112)
+	// sample returns the cumulative prices of a pair with a constant price0 over time
+	sample := func(timestamp uint64, price0Cumulative *big.Int) uniswapV2Sample {
+		return uniswapV2Sample{
+			timestamp:        timestamp,
+			price0Cumulative: price0Cumulative,
+			price1Cumulative: big.NewInt(0),
+		}
+	}
+	cumulative := func(price int64, seconds int64) *big.Int {
+		return new(big.Int).Mul(new(big.Int).Mul(q112, big.NewInt(price)), big.NewInt(seconds))
+	}
+
+	_, _, err := samples.average(address, sample(1000, cumulative(2, 0)), 60)
+	require.ErrorContains(t, err, "not enough samples")
+
+	_, _, err = samples.average(address, sample(1030, cumulative(2, 30)), 60)
+	require.ErrorContains(t, err, "not enough samples")
+
+	// price 2 for 60s, then a price of 100 for 10s
+	average0, _, err := samples.average(address, sample(1070, new(big.Int).Add(cumulative(2, 60), cumulative(100, 10))), 60)
+	require.NoError(t, err)
+	averageFloat, _ := average0.Float64()
+	require.InDelta(t, (2.0*60+100*10)/70, averageFloat, 1e-9)
+
+	// the window now starts at the second sample, the first one is no longer needed
+	average0, _, err = samples.average(address, sample(1100, new(big.Int).Add(cumulative(2, 60), cumulative(100, 40))), 60)
+	require.NoError(t, err)
+	averageFloat, _ = average0.Float64()
+	require.InDelta(t, (2.0*30+100*40)/70, averageFloat, 1e-9)
+	require.Len(t, history.samples[address], 3)
+
+	t.Run("kept across restarts", func(t *testing.T) {
+		// a restarted source prices the pair right away
+		restarted := newUniswapV2Samples(symbols, history)
+		average0, _, err := restarted.average(address, sample(1110, new(big.Int).Add(cumulative(2, 60), cumulative(100, 50))), 60)
 		require.NoError(t, err)
-		require.Equal(t, 2, len(rawPrices))
-		fmt.Println(rawPrices)
-		require.NotZero(t, rawPrices["ETHUSD"])
-		require.NotZero(t, rawPrices["VSGUSD"])
+		averageFloat, _ := average0.Float64()
+		require.InDelta(t, (2.0*30+100*50)/80, averageFloat, 1e-9)
+
+		// the samples of a pair which is no longer configured are dropped
+		newUniswapV2Samples(map[types.Symbol]UniswapSymbol{}, history)
+		require.Empty(t, history.samples)
+	})
+
+	t.Run("overflowing cumulative price", func(t *testing.T) {
+		maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+		start := new(big.Int).Sub(maxUint256, cumulative(1, 10))
+		end := new(big.Int).Sub(cumulative(3, 60), cumulative(1, 10))
+		end.Sub(end, big.NewInt(1))
+		average := cumulativeAverage(start, end, big.NewFloat(60))
+		averageFloat, _ := average.Float64()
+		require.InDelta(t, 3, averageFloat, 1e-9)
 	})
 }
+
+func TestAverageTick(t *testing.T) {
+	require.Equal(t, int64(10), averageTick(big.NewInt(0), big.NewInt(100), 10))
+	// rounds towards negative infinity like the Uniswap oracle library
+	require.Equal(t, int64(-11), averageTick(big.NewInt(0), big.NewInt(-101), 10))
+}
+
+func TestTickRatio(t *testing.T) {
+	for _, tick := range []int64{0, 1, -1, 6932, -6932, 50000} {
+		// 1.0001^tick = 10001^tick / 10000^tick
+		power := uint64(tick)
+		if tick < 0 {
+			power = uint64(-tick)
+		}
+		num := new(big.Int).Exp(big.NewInt(10001), new(big.Int).SetUint64(power), nil)
+		denom := new(big.Int).Exp(big.NewInt(10000), new(big.Int).SetUint64(power), nil)
+		if tick < 0 {
+			num, denom = denom, num
+		}
+		expected := new(big.Rat).SetFrac(num, denom).FloatString(sdkmath.LegacyPrecision)
+		require.Equal(t, expected, tickRatio(tick).Text('f', sdkmath.LegacyPrecision), "tick %d", tick)
+	}
+}
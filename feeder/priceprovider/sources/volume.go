@@ -0,0 +1,33 @@
+package sources
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+
+	sdkmath "cosmossdk.io/math"
+)
+
+// parseVolume parses a 24h volume as reported by a source. Volumes are optional:
+// one which is missing or cannot be parsed is zero, which only disables volume
+// weighting for the pair instead of discarding its price.
+func parseVolume(s string) float64 {
+	volume, err := strconv.ParseFloat(s, 64)
+	if err != nil || volume < 0 || math.IsNaN(volume) || math.IsInf(volume, 0) {
+		return 0
+	}
+	return volume
+}
+
+// quoteVolume returns the volume in base asset reported by a source as a volume in quote
+// asset at the given price, rounded to a float64 once. Like parseVolume, it is zero if
+// the volume cannot be parsed.
+func quoteVolume(baseVolume string, price sdkmath.LegacyDec) float64 {
+	volume, ok := new(big.Rat).SetString(baseVolume)
+	if !ok || volume.Sign() < 0 {
+		return 0
+	}
+	volume.Mul(volume, new(big.Rat).SetFrac(price.BigInt(), sdkmath.LegacyOneDec().BigInt()))
+	quote, _ := volume.Float64()
+	return quote
+}
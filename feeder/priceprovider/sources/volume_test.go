@@ -0,0 +1,25 @@
+package sources
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVolume(t *testing.T) {
+	require.Equal(t, 1234.5, parseVolume("1234.5"))
+	require.Equal(t, 0.0, parseVolume(""))
+	require.Equal(t, 0.0, parseVolume("invalid"))
+	require.Equal(t, 0.0, parseVolume("-1"))
+	require.Equal(t, 0.0, parseVolume("NaN"))
+	require.Equal(t, 0.0, parseVolume("Inf"))
+}
+
+func TestQuoteVolume(t *testing.T) {
+	price := sdkmath.LegacyMustNewDecFromStr("0.000012345678901234")
+	require.Equal(t, 12.345678901234, quoteVolume("1000000", price))
+	require.Equal(t, 0.0, quoteVolume("", price))
+	require.Equal(t, 0.0, quoteVolume("invalid", price))
+	require.Equal(t, 0.0, quoteVolume("-1", price))
+}
@@ -13,6 +13,10 @@ type EventStream interface {
 	// VotingPeriodStarted signals a new x/oracle
 	// voting period has just started.
 	VotingPeriodStarted() <-chan VotingPeriod
+	// Connected signals the changes of the connection
+	// to the chain: true once (re)connected, false once
+	// disconnected, the EventStream reconnecting by itself.
+	Connected() <-chan bool
 	// Close shuts down the EventStream.
 	Close()
 }
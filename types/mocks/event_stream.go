@@ -46,6 +46,20 @@ func (mr *MockEventStreamMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockEventStream)(nil).Close))
 }
 
+// Connected mocks base method.
+func (m *MockEventStream) Connected() <-chan bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connected")
+	ret0, _ := ret[0].(<-chan bool)
+	return ret0
+}
+
+// Connected indicates an expected call of Connected.
+func (mr *MockEventStreamMockRecorder) Connected() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connected", reflect.TypeOf((*MockEventStream)(nil).Connected))
+}
+
 // ParamsUpdate mocks base method.
 func (m *MockEventStream) ParamsUpdate() <-chan types.Params {
 	m.ctrl.T.Helper()
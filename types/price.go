@@ -1,8 +1,11 @@
 package types
 
 import (
+	"fmt"
+	"math/big"
 	"time"
 
+	sdkmath "cosmossdk.io/math"
 	"github.com/rs/zerolog"
 	"github.com/vsc-blockchain/core/x/common/asset"
 	"github.com/vsc-blockchain/core/x/common/set"
@@ -13,7 +16,10 @@ const (
 )
 
 type RawPrice struct {
-	Price      float64
+	Price sdkmath.LegacyDec
+	// Volume is the 24h traded volume denominated in the quote asset.
+	// Zero if the source does not report volumes.
+	Volume     float64
 	UpdateTime time.Time
 }
 
@@ -22,7 +28,10 @@ type Price struct {
 	// Pair defines the symbol we're posting prices for.
 	Pair asset.Pair
 	// Price defines the symbol's price.
-	Price float64
+	Price sdkmath.LegacyDec
+	// Volume defines the 24h quote volume reported by the source,
+	// zero if unknown. Used to weight the price during aggregation.
+	Volume float64
 	// SourceName defines the source which is providing the prices.
 	SourceName string
 	// Valid reports whether the price is valid or not.
@@ -33,7 +42,31 @@ type Price struct {
 
 // FetchPricesFunc is the function used to fetch updated prices.
 // The symbols passed are the symbols we require prices for.
-// The returned map must map symbol to its float64 price, or an error.
+// The returned map must map symbol to its RawPrice, or an error.
+// RawPrice.UpdateTime does not need to be set, RawPrice.Volume
+// can be left at zero if the source does not provide volumes.
 // If there's a failure in updating only one price then the map can be returned
 // without the provided symbol.
-type FetchPricesFunc func(symbols set.Set[Symbol], logger zerolog.Logger) (map[Symbol]float64, error)
+type FetchPricesFunc func(symbols set.Set[Symbol], logger zerolog.Logger) (map[Symbol]RawPrice, error)
+
+// precisionMultiplier scales a price to the fixed precision of a LegacyDec.
+var precisionMultiplier = new(big.Int).Exp(big.NewInt(10), big.NewInt(sdkmath.LegacyPrecision), nil)
+
+// ParsePrice parses a price as reported by the sources, in decimal or
+// scientific notation, into a LegacyDec without going through a float64.
+// Digits beyond the 18 decimals of a LegacyDec are truncated.
+func ParsePrice(s string) (sdkmath.LegacyDec, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return sdkmath.LegacyDec{}, fmt.Errorf("invalid price: %q", s)
+	}
+	if r.Sign() < 0 {
+		return sdkmath.LegacyDec{}, fmt.Errorf("negative price: %q", s)
+	}
+	scaled := new(big.Int).Mul(r.Num(), precisionMultiplier)
+	scaled.Quo(scaled, r.Denom())
+	if scaled.BitLen() > sdkmath.MaxBitLen {
+		return sdkmath.LegacyDec{}, fmt.Errorf("price out of range: %q", s)
+	}
+	return sdkmath.LegacyNewDecFromBigIntWithPrec(scaled, sdkmath.LegacyPrecision), nil
+}
@@ -0,0 +1,28 @@
+package types
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrice(t *testing.T) {
+	for input, expected := range map[string]string{
+		"42000.5":                      "42000.5",
+		"0.000000012345678901":         "0.000000012345678901",
+		"1.2345e-05":                   "0.000012345",
+		"1E3":                          "1000",
+		"0.1234567890123456789":        "0.123456789012345678", // truncated to 18 decimals
+		"98765432109876543210.0000001": "98765432109876543210.0000001",
+	} {
+		price, err := ParsePrice(input)
+		require.NoError(t, err, input)
+		require.True(t, sdkmath.LegacyMustNewDecFromStr(expected).Equal(price), "%s: got %s", input, price)
+	}
+
+	for _, input := range []string{"", "abc", "1.2.3", "-1", "1e100"} {
+		_, err := ParsePrice(input)
+		require.Error(t, err, input)
+	}
+}
@@ -2,61 +2,59 @@ package types
 
 import (
 	"encoding/json"
-	"strconv"
-	"time"
+	"errors"
+	"fmt"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	cmttypes "github.com/cometbft/cometbft/types"
 )
 
-func GetBlockHeight(msg []byte) (uint64, error) {
-	t := new(NewBlockJSON)
-	err := json.Unmarshal(msg, t)
-	if err != nil {
-		return 0, err
+// NewBlockEventsQuery is the CometBFT subscription query of the block events, which carry
+// the height and the events of the blocks without their txs.
+const NewBlockEventsQuery = "tm.event='NewBlockEvents'"
+
+// ErrSubscription is returned for the JSON-RPC error responses of an event subscription.
+var ErrSubscription = errors.New("subscription error")
+
+// ParseNewBlockEvents decodes a message of the NewBlockEventsQuery subscription. It returns nil
+// for the response confirming the subscription, and an ErrSubscription for a JSON-RPC error.
+func ParseNewBlockEvents(msg []byte) (*cmttypes.EventDataNewBlockEvents, error) {
+	var resp rpctypes.RPCResponse
+	if err := json.Unmarshal(msg, &resp); err != nil {
+		return nil, err
 	}
-	if t.Result.Data.Value.Block.Header.Height == "" {
-		return 0, nil
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSubscription, resp.Error)
+	}
+	if len(resp.Result) == 0 {
+		return nil, errors.New("empty response")
 	}
-	return strconv.ParseUint(t.Result.Data.Value.Block.Header.Height, 10, 64)
-}
-
-// todo mercilex split in concrete types instead of anonymous
-type NewBlockJSON struct {
-	Jsonrpc string `json:"jsonrpc"`
-	ID      int    `json:"id"`
-	Result  struct {
-		Query string `json:"query"`
-		Data  struct {
-			Type  string `json:"type"`
-			Value struct {
-				Block struct {
-					Header struct {
-						ChainID        string    `json:"chain_id"`
-						Height         string    `json:"height"`
-						Time           time.Time `json:"time"`
-						LastCommitHash string    `json:"last_commit_hash"`
-					} `json:"header"`
-					Data struct {
-						Txs []interface{} `json:"txs"`
-					} `json:"data"`
-				} `json:"block"`
-				ResultBeginBlock struct {
-					Events []TmEvent `json:"events"`
-				} `json:"result_begin_block"`
-				ResultEndBlock struct {
-					ValidatorUpdates []interface{} `json:"validator_updates"`
-					Events           []TmEvent     `json:"events"`
-				} `json:"result_end_block"`
-			} `json:"value"`
-		} `json:"data"`
-	} `json:"result"`
-}
 
-type TmEvent struct {
-	Type       string `json:"type"`
-	Attributes []TmEventAttribute
+	var result coretypes.ResultEvent
+	if err := cmtjson.Unmarshal(resp.Result, &result); err != nil {
+		return nil, err
+	}
+	switch data := result.Data.(type) {
+	case nil:
+		return nil, nil
+	case cmttypes.EventDataNewBlockEvents:
+		return &data, nil
+	default:
+		return nil, fmt.Errorf("unexpected event data %T", data)
+	}
 }
 
-type TmEventAttribute struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-	Index bool   `json:"index"`
+// HasEvent reports whether the events contain an event of one of the given types.
+func HasEvent(events []abcitypes.Event, eventTypes ...string) bool {
+	for _, event := range events {
+		for _, eventType := range eventTypes {
+			if event.Type == eventType {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNewBlockEvents(t *testing.T) {
+	t.Run("block events", func(t *testing.T) {
+		block, err := ParseNewBlockEvents([]byte(`{"jsonrpc":"2.0","id":0,"result":{"query":"tm.event='NewBlockEvents'",
+			"data":{"type":"tendermint/event/NewBlockEvents","value":{"height":"42","num_txs":"3",
+			"events":[{"type":"active_proposal","attributes":[{"key":"proposal_id","value":"1","index":true}]}]}},
+			"events":{"tm.event":["NewBlockEvents"]}}}`))
+		require.NoError(t, err)
+		require.Equal(t, int64(42), block.Height)
+		require.True(t, HasEvent(block.Events, "transfer", "active_proposal"))
+		require.False(t, HasEvent(block.Events, "transfer"))
+	})
+
+	t.Run("subscription confirmed", func(t *testing.T) {
+		block, err := ParseNewBlockEvents([]byte(`{"jsonrpc":"2.0","id":0,"result":{}}`))
+		require.NoError(t, err)
+		require.Nil(t, block)
+	})
+
+	t.Run("json-rpc error", func(t *testing.T) {
+		_, err := ParseNewBlockEvents([]byte(`{"jsonrpc":"2.0","id":0,"error":{"code":-32603,"message":"Internal error","data":"subscription was canceled"}}`))
+		require.ErrorIs(t, err, ErrSubscription)
+		require.ErrorContains(t, err, "subscription was canceled")
+	})
+
+	t.Run("unexpected event", func(t *testing.T) {
+		_, err := ParseNewBlockEvents([]byte(`{"jsonrpc":"2.0","id":0,"result":{"query":"tm.event='NewBlockHeader'",
+			"data":{"type":"tendermint/event/NewBlockHeader","value":{"header":{"height":"42"}}}}}`))
+		require.ErrorContains(t, err, "unexpected event data")
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := ParseNewBlockEvents([]byte("{"))
+		require.Error(t, err)
+	})
+}
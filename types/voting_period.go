@@ -5,4 +5,10 @@ package types
 type VotingPeriod struct {
 	// Height is the height of the voting period.
 	Height uint64
+	// EndHeight is the height of the next voting period,
+	// or zero if unknown.
+	EndHeight uint64
+	// CatchUp reports whether the first block of the voting
+	// period was missed, the period being signaled late.
+	CatchUp bool
 }